@@ -8,7 +8,7 @@ import (
 	"github.com/hellgate75/go-deploy/types/module"
 )
 
-// Loads Config Type, Network Type and Plugins Type files and merge them, saving in the Runtime package variables
+// Loads Config Type, Network Type, Plugins Type and Inventory files and merge them, saving in the Runtime package variables
 func (bootstrap *bootstrap) Load(baseDir string, suffix string, format module.DescriptorTypeValue, logger log.Logger) []error {
 	if baseDir == "" {
 		baseDir = "./" + DEFAULT_CONFIG_FOLDER
@@ -30,6 +30,7 @@ func (bootstrap *bootstrap) Load(baseDir string, suffix string, format module.De
 	var dataFileObjectList []*module.DeployType = make([]*module.DeployType, 0)
 	var netFileObjectList []*module.NetProtocolType = make([]*module.NetProtocolType, 0)
 	var pluginFileObjectList []*module.PluginsConfig = make([]*module.PluginsConfig, 0)
+	var inventoryFileObjectList []*module.InventoryConfig = make([]*module.InventoryConfig, 0)
 
 	var dataFileList []string = io.FindFilesIn(baseDir, true, DEPLOY_DATA_FILE_NAME+suffixString)
 	if "" != suffixString && len(dataFileList) == 0 {
@@ -163,6 +164,35 @@ func (bootstrap *bootstrap) Load(baseDir string, suffix string, format module.De
 			}
 		}
 	}
+
+	var inventoryFileList []string = io.FindFilesIn(baseDir, true, DEPLOY_INVENTORY_FILE_NAME+suffixString)
+	if "" != suffixString && len(inventoryFileList) == 0 {
+		inventoryFileList = io.FindFilesIn(baseDir, true, DEPLOY_INVENTORY_FILE_NAME)
+	}
+	for _, inventoryFilePath := range inventoryFileList {
+		logger.Debug("inventoryFilePath:" + inventoryFilePath)
+		var files []string = []string{inventoryFilePath}
+		if io.IsFolder(inventoryFilePath) {
+			files = io.GetMatchedFiles(inventoryFilePath, true, matcher)
+		}
+		for _, inventoryFilePathX := range files {
+			var iConfig *module.InventoryConfig = &module.InventoryConfig{}
+			var errX error = nil
+			dformat := GetFileFormatDescritor(inventoryFilePathX, format)
+			if dformat == module.YAML_DESCRIPTOR {
+				iConfig, errX = iConfig.FromYamlFile(inventoryFilePathX)
+			} else if dformat == module.XML_DESCRIPTOR {
+				iConfig, errX = iConfig.FromXmlFile(inventoryFilePathX)
+			} else if dformat == module.JSON_DESCRIPTOR {
+				iConfig, errX = iConfig.FromJsonFile(inventoryFilePathX)
+			}
+			if errX != nil {
+				errorsList = append(errorsList, errX)
+			} else {
+				inventoryFileObjectList = append(inventoryFileObjectList, iConfig)
+			}
+		}
+	}
 	
 	var deployType *module.DeployType = nil
 
@@ -200,6 +230,18 @@ func (bootstrap *bootstrap) Load(baseDir string, suffix string, format module.De
 	}
 	
 	bootstrap.pluginsType = pluginsType
+
+	var inventoryConfig *module.InventoryConfig = nil
+
+	for _, inventoryConfigX := range inventoryFileObjectList {
+		if inventoryConfig == nil {
+			inventoryConfig = inventoryConfigX
+		} else {
+			inventoryConfig = inventoryConfig.Merge(inventoryConfigX)
+		}
+	}
+
+	bootstrap.inventoryConfig = inventoryConfig
 	
 	return errorsList
 }
@@ -2,10 +2,16 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"github.com/hellgate75/go-tcp-common/io"
+	"github.com/hellgate75/go-deploy/net"
+	"github.com/hellgate75/go-deploy/net/generic"
 	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
 	"github.com/hellgate75/go-deploy/types/module"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func loadVarsFiles() ([]defaults.NameValue, error) {
@@ -55,6 +61,19 @@ func loadHostsFiles() ([]defaults.HostGroups, error) {
 	var configDir string = module.RuntimeDeployConfig.ConfigDir
 	var hostsfiles []string = module.RuntimeDeployConfig.UseHosts
 	for _, hostsFile := range hostsfiles {
+		if inventory.IsDynamic(hostsFile, configDir) {
+			Logger.Debug("Loading dynamic inventory: " + hostsFile)
+			ttl, err := inventoryTTL()
+			if err != nil {
+				return hostsList, errors.New("bootstrap.loadHostsFiles -> Cause:" + err.Error())
+			}
+			groups, err := inventory.LoadDynamic(hostsFile, configDir, module.RuntimeDeployConfig.SystemDir, ttl)
+			if err != nil {
+				return hostsList, errors.New("bootstrap.loadHostsFiles -> Cause:" + err.Error())
+			}
+			hostsList = append(hostsList, groups...)
+			continue
+		}
 		var index int = strings.Index(hostsFile, ".")
 		var ext string = ""
 		if index < 1 {
@@ -89,9 +108,37 @@ func loadHostsFiles() ([]defaults.HostGroups, error) {
 		}
 		hostsList = append(hostsList, hostsFileObj.HostGroups...)
 	}
+	if module.RuntimeInventoryConfig != nil && len(module.RuntimeInventoryConfig.Providers) > 0 {
+		Logger.Debugf("Loading %v inventory providers", len(module.RuntimeInventoryConfig.Providers))
+		ttl, err := inventoryTTL()
+		if err != nil {
+			return hostsList, errors.New("bootstrap.loadHostsFiles -> Cause:" + err.Error())
+		}
+		groups, err := inventory.LoadProviders(module.RuntimeInventoryConfig, configDir, module.RuntimeDeployConfig.SystemDir, ttl)
+		if err != nil {
+			return hostsList, errors.New("bootstrap.loadHostsFiles -> Cause:" + err.Error())
+		}
+		hostsList = append(hostsList, groups...)
+	}
 	return hostsList, nil
 }
 
+// Retrieves the dynamic inventories and inventory providers cache time to live, as Go duration string (e.g.: 10m) or number of seconds
+func inventoryTTL() (time.Duration, error) {
+	var text string = strings.TrimSpace(module.RuntimeDeployConfig.InventoryTTL)
+	if text == "" {
+		return inventory.DEFAULT_INVENTORY_TTL, nil
+	}
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	ttl, err := time.ParseDuration(text)
+	if err != nil || ttl < 0 {
+		return 0, errors.New(fmt.Sprintf("Invalid inventory ttl %s, expected a duration (e.g.: 10m) or a number of seconds", text))
+	}
+	return ttl, nil
+}
+
 func loadEnvsFile() ([]defaults.NameValue, error) {
 	var envsList []defaults.NameValue = make([]defaults.NameValue, 0)
 	var configDir string = module.RuntimeDeployConfig.ConfigDir
@@ -120,3 +167,51 @@ func loadEnvsFile() ([]defaults.NameValue, error) {
 	envsList = append(envsList, envsFileObj.Envs...)
 	return envsList, nil
 }
+
+// Creates the Connection Handler and the authentication configuration for the given network configuration,
+// connection handler factories are discovered once per protocol and kept in the given cache
+func newConnectionHandler(netConfig *module.NetProtocolType, factories map[module.NetProtocolTypeValue]generic.NewConnectionHandlerFunc) (generic.ConnectionHandler, module.ConnectionConfig, error) {
+	var protocol string = string(netConfig.NetProtocol)
+	handlerEnvelope, ok := factories[netConfig.NetProtocol]
+	if !ok {
+		var errHandler error
+		handlerEnvelope, errHandler = net.DiscoverConnectionHandler(protocol)
+		if errHandler != nil {
+			return nil, module.ConnectionConfig{}, errors.New("Unable to determine the Connection Handler: " + errHandler.Error())
+		}
+		if handlerEnvelope == nil {
+			return nil, module.ConnectionConfig{}, errors.New(fmt.Sprintf("Unable to create ConnectionHandler for type: %s", protocol))
+		}
+		factories[netConfig.NetProtocol] = handlerEnvelope
+	}
+	handler, handlerConfig := handlerEnvelope(module.RuntimeDeployConfig.SingleSession, netConfig.Insecure)
+	if module.RuntimePluginsType.EnableDeployClientCommandsPlugin {
+		handler.UsePlugins(module.RuntimePluginsType.DeployClientCommandsPluginExtension, module.RuntimePluginsType.DeployClientCommandsPluginFolder)
+	}
+	var missKey bool = netConfig.KeyFile == ""
+	var missPassPhrase bool = netConfig.Passphrase == ""
+	var missUser bool = netConfig.UserName == ""
+	var missPassword bool = netConfig.Password == ""
+	var missCertificate bool = netConfig.Certificate == ""
+	var connectionConfig module.ConnectionConfig = module.ConnectionConfig{
+		UseSSHConfig: netConfig.UseSSHConfig && handlerConfig.UseSSHConfig,
+	}
+	if netConfig.UseSSHConfig && !handlerConfig.UseSSHConfig {
+		Logger.Warn("SSH config files are not supported by the connection handler for: " + protocol)
+	}
+	if !missUser && !missPassword && handlerConfig.UseUserPassword {
+		connectionConfig.UseUserPassword = true
+	} else if !missUser && !missKey && missPassPhrase && handlerConfig.UseAuthKey {
+		connectionConfig.UseUserKey = true
+	} else if !missUser && !missKey && !missPassPhrase && handlerConfig.UseAuthKeyPassphrase {
+		connectionConfig.UseUserKeyPassphrase = true
+	} else if !missKey && !missCertificate && handlerConfig.UseCertificates {
+		connectionConfig.UseTLSCertificates = true
+	} else if connectionConfig.UseSSHConfig && handlerConfig.UseAuthKey {
+		// User and identity file are resolved from the SSH config files
+		connectionConfig.UseUserKey = true
+	} else {
+		return nil, module.ConnectionConfig{}, errors.New("Missing mandatory authentication user and/or passoword and/or rsa public key / TLS Key file or certificates for client type: " + protocol)
+	}
+	return handler, connectionConfig, nil
+}
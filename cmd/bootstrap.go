@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/hellgate75/go-tcp-common/io"
 	"github.com/hellgate75/go-deploy/net"
+	"github.com/hellgate75/go-deploy/types/defaults"
 	"github.com/hellgate75/go-deploy/types/module"
 	"github.com/hellgate75/go-tcp-client/client/proxy"
 	modproxy "github.com/hellgate75/go-deploy/modules/proxy"
@@ -17,6 +18,7 @@ const (
 	DEPLOY_DATA_FILE_NAME   string = "deploy-type"
 	DEPLOY_NET_FILE_NAME    string = "deploy-net"
 	DEPLOY_PKUGINS_FILE_NAME    string = "deploy-plugins"
+	DEPLOY_INVENTORY_FILE_NAME  string = "deploy-inventory"
 	DEPLOY_ENVS_FILE_NAME   string = "deploy-envs"
 	DEFAULT_CONFIG_FOLDER   string = "env"
 	DEFAULT_CHARTS_FOLDER   string = "charts"
@@ -31,9 +33,11 @@ type Bootstrap interface {
 	Init(baseDir string, suffix string, format module.DescriptorTypeValue, logger log.Logger) []error
 	Load(baseDir string, suffix string, format module.DescriptorTypeValue, logger log.Logger) []error
 	Run(feed *module.FeedExec, logger log.Logger) []error
+	RunWith(feed *module.FeedExec, overrides RunOverrides, logger log.Logger) []error
 	GetDeployConfig() *module.DeployConfig
 	GetDeployType() *module.DeployType
 	GetPluginsType() *module.PluginsConfig
+	GetInventoryConfig() *module.InventoryConfig
 	GetNetType() *module.NetProtocolType
 	GetDefaultDeployConfig() *module.DeployConfig
 	GetDefaultDeployType() *module.DeployType
@@ -41,11 +45,20 @@ type Bootstrap interface {
 	GetDefaultPluginsType() *module.PluginsConfig
 }
 
+// Runtime overrides for a single deploy run, nil elements are loaded from the configuration files.
+// Extra variables are merged over the loaded or provided variables
+type RunOverrides struct {
+	HostGroups []defaults.HostGroups
+	Vars       []defaults.NameValue
+	ExtraVars  []defaults.NameValue
+}
+
 type bootstrap struct {
 	deployConfig *module.DeployConfig
 	deployType   *module.DeployType
 	netType      *module.NetProtocolType
 	pluginsType      *module.PluginsConfig
+	inventoryConfig  *module.InventoryConfig
 }
 
 func (bootstrap *bootstrap) GetDeployConfig() *module.DeployConfig {
@@ -64,6 +77,10 @@ func (bootstrap *bootstrap) GetPluginsType() *module.PluginsConfig {
 	return bootstrap.pluginsType
 }
 
+func (bootstrap *bootstrap) GetInventoryConfig() *module.InventoryConfig {
+	return bootstrap.inventoryConfig
+}
+
 func (bootstrap *bootstrap) GetDefaultDeployConfig() *module.DeployConfig {
 	dt, err := ParseArguments()
 	if err != nil {
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"github.com/gookit/color"
 	"github.com/hellgate75/go-tcp-common/io"
-	"github.com/hellgate75/go-deploy/net"
+	"github.com/hellgate75/go-deploy/net/generic"
 	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
 	"github.com/hellgate75/go-deploy/types/module"
 	"github.com/hellgate75/go-deploy/worker"
 	"github.com/hellgate75/go-tcp-client/client/proxy"
@@ -16,7 +17,12 @@ import (
 
 // Start Deploy Process.
 func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []error {
-	var errorsList []error = make([]error, 0)
+	return bootstrap.RunWith(feed, RunOverrides{}, logger)
+}
+
+// Start Deploy Process, using given host groups and/or variables in place of the configured ones.
+func (bootstrap *bootstrap) RunWith(feed *module.FeedExec, overrides RunOverrides, logger log.Logger) (errorsList []error) {
+	errorsList = make([]error, 0)
 	defer func() {
 		if r := recover(); r != nil {
 			var message string = fmt.Sprintf("cmd.Bootstrap.Run - Recovery:\n- %v", r)
@@ -24,10 +30,22 @@ func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []erro
 			errorsList = append(errorsList, errors.New(fmt.Sprintf("%v", r)))
 		}
 	}()
-	hosts, errH := loadHostsFiles()
-	if errH != nil || len(hosts) == 0 {
-		Logger.Error("Unable to load hosts...")
-		Logger.Error("Reason:", errH)
+	var hosts []defaults.HostGroups = overrides.HostGroups
+	if hosts == nil {
+		var errH error
+		hosts, errH = loadHostsFiles()
+		if errH != nil || len(hosts) == 0 {
+			Logger.Error("Unable to load hosts...")
+			Logger.Error("Reason:", errH)
+			panic("Exit the procedure!!")
+		}
+	} else {
+		Logger.Info("Using provided host groups ...")
+	}
+	hosts, errC := inventory.ExpandChildren(hosts)
+	if errC != nil {
+		Logger.Error("Unable to resolve host groups children...")
+		Logger.Error("Reason:", errC)
 		panic("Exit the procedure!!")
 	}
 	envs, errE := loadEnvsFile()
@@ -36,11 +54,20 @@ func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []erro
 		Logger.Warn("Reason:", errE)
 		Logger.Warn("We trust whatever you pass as environment!!")
 	}
-	vars, errV := loadVarsFiles()
-	if errV != nil {
-		Logger.Warn("Unable to load Vars...")
-		Logger.Warn("Reason:", errV)
-		Logger.Warn("Continue without any initial Variable!!")
+	var vars []defaults.NameValue = overrides.Vars
+	if vars == nil {
+		var errV error
+		vars, errV = loadVarsFiles()
+		if errV != nil {
+			Logger.Warn("Unable to load Vars...")
+			Logger.Warn("Reason:", errV)
+			Logger.Warn("Continue without any initial Variable!!")
+		}
+	} else {
+		Logger.Info("Using provided variables ...")
+	}
+	if len(overrides.ExtraVars) > 0 {
+		Logger.Infof("Using %v extra variable(s) ...", len(overrides.ExtraVars))
 	}
 	envsYaml, _ := io.ToYaml(envs)
 	hostsYaml, _ := io.ToYaml(hosts)
@@ -52,53 +79,8 @@ func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []erro
 	Logger.Debugf("\nConfig: %s\nType: %s\nNet: %s", configYaml, typeYaml, netYaml)
 
 	Logger.Info("Connection Protocol: " + color.Yellow.Render(string(module.RuntimeNetworkType.NetProtocol)))
-	handlerEnvelope, errHandler := net.DiscoverConnectionHandler(string(module.RuntimeNetworkType.NetProtocol))
-	if errHandler != nil {
-		Logger.Error("Unable to determine the Connection Handler for: " + string(module.RuntimeNetworkType.NetProtocol))
-		panic("Unable to determine the Connection Handler: " + errHandler.Error())
-	}
-	if handlerEnvelope == nil {
-		var message string = fmt.Sprintf("Unable to create ConnectionHandler for type: %s", string(module.RuntimeNetworkType.NetProtocol))
-		Logger.Error(message)
-		panic(message)
-	}
-	handler, handlerConfig := handlerEnvelope(module.RuntimeDeployConfig.SingleSession, module.RuntimeNetworkType.Insecure)
-	if module.RuntimePluginsType.EnableDeployClientCommandsPlugin {
-		handler.UsePlugins(module.RuntimePluginsType.DeployClientCommandsPluginExtension, module.RuntimePluginsType.DeployClientCommandsPluginFolder)
-	}
-	Logger.Infof("Connection Handler loaded: %s", color.Yellow.Render(fmt.Sprintf("%v", (handler != nil))))
-	Logger.Warn("Using "+string(module.RuntimeNetworkType.NetProtocol)+" protocol ...")
-	var missKey bool = module.RuntimeNetworkType == nil || module.RuntimeNetworkType.KeyFile == ""
-	var missPassPhrase bool = module.RuntimeNetworkType == nil || module.RuntimeNetworkType.Passphrase == ""
-	var missUser bool = module.RuntimeNetworkType == nil || module.RuntimeNetworkType.UserName == ""
-	var missPassword bool = module.RuntimeNetworkType == nil || module.RuntimeNetworkType.Password == ""
-	var missCertificate bool = module.RuntimeNetworkType == nil || module.RuntimeNetworkType.Certificate == ""
-	var useUserPassword bool = false
-	var useUserKey bool = false
-	var useUserKeyPassphrase bool = false
-	var useCertificates bool = false
-	if !missUser && !missPassword && handlerConfig.UseUserPassword {
-		useUserPassword = true
-	} else if !missUser && !missKey && missPassPhrase && handlerConfig.UseAuthKey{
-		useUserKey = true
-	} else if !missUser && !missKey && !missPassPhrase && handlerConfig.UseAuthKeyPassphrase {
-		useUserKeyPassphrase = true
-	} else if !missKey && !missCertificate && handlerConfig.UseCertificates {
-		useCertificates = true
-	} else {
-		var message string = "Missing mandatory authentication user and/or passoword and/or rsa public key / TLS Key file or certificates for client type: " + string(module.RuntimeNetworkType.NetProtocol)
-		Logger.Error(message)
-		panic(message)
-	}
-	
-	var connectionConfig module.ConnectionConfig = module.ConnectionConfig{
-		UseUserPassword: useUserPassword,
-		UseUserKey: useUserKey,
-		UseSSHConfig: false,
-		UseUserKeyPassphrase: useUserKeyPassphrase,
-		UseTLSCertificates: useCertificates,
-	}
-	
+	var factories map[module.NetProtocolTypeValue]generic.NewConnectionHandlerFunc = make(map[module.NetProtocolTypeValue]generic.NewConnectionHandlerFunc)
+
 	var sessionsMap map[string]module.Session = make(map[string]module.Session)
 
 	for _, hg := range hosts {
@@ -106,17 +88,31 @@ func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []erro
 			var hostSessionMapKey string = hg.Name + "-" + hostValue.Name
 			sessionsMap[hostSessionMapKey] = module.NewSession(module.NewSessionId())
 			Logger.Debugf("Create session for host: %s -> Session Id: %s", color.Yellow.Render(hostValue.Name), color.Yellow.Render(sessionsMap[hostSessionMapKey].GetSessionId()))
-			for _, variable := range vars {
+			// Host variables override the host group ones, which override the global ones, extra variables override all of them
+			var hostVars []defaults.NameValue = defaults.MergeVars(defaults.MergeVars(defaults.MergeVars(vars, hg.Vars), hostValue.Vars), overrides.ExtraVars)
+			for _, variable := range hostVars {
 				Logger.Debugf("Create session variable for host: %s -> Name: %s  Value: %s", color.Yellow.Render(hostValue.Name), variable.Name, variable.Value)
 				sessionsMap[hostSessionMapKey].SetVar(variable.Name, variable.Value)
 			}
-			sessionsMap[hostSessionMapKey].SetSystemObject("connection-handler", handler.Clone())
+			// Host connection settings override the host group ones, which override the network configuration
+			var hostNet *module.NetProtocolType = hostValue.Connection.Apply(hg.Connection.Apply(module.RuntimeNetworkType))
+			hostHandler, hostConnectionConfig, errHandler := newConnectionHandler(hostNet, factories)
+			if errHandler != nil {
+				// The host fails, other hosts keep running the feed
+				var message string = fmt.Sprintf("Host %s: %s", hostValue.Name, errHandler.Error())
+				Logger.Error(message)
+				sessionsMap[hostSessionMapKey].SetSystemObject("connection-error", errors.New(message))
+			} else {
+				Logger.Debugf("Create connection handler for host: %s -> Protocol: %s", color.Yellow.Render(hostValue.Name), color.Yellow.Render(string(hostNet.NetProtocol)))
+				sessionsMap[hostSessionMapKey].SetSystemObject("connection-handler", hostHandler)
+				sessionsMap[hostSessionMapKey].SetSystemObject("connection-config", hostConnectionConfig)
+			}
 			sessionsMap[hostSessionMapKey].SetSystemObject("rutime-config", module.RuntimeDeployConfig)
 			sessionsMap[hostSessionMapKey].SetSystemObject("runtime-type", module.RuntimeDeployType)
-			sessionsMap[hostSessionMapKey].SetSystemObject("runtime-net", module.RuntimeNetworkType)
+			sessionsMap[hostSessionMapKey].SetSystemObject("runtime-net", hostNet)
 			sessionsMap[hostSessionMapKey].SetSystemObject("host-groups", hosts)
 			sessionsMap[hostSessionMapKey].SetSystemObject("envs", envs)
-			sessionsMap[hostSessionMapKey].SetSystemObject("vars", vars)
+			sessionsMap[hostSessionMapKey].SetSystemObject("vars", hostVars)
 			sessionsMap[hostSessionMapKey].SetSystemObject("system-logger", logger)
 		}
 	}
@@ -132,15 +128,20 @@ func (bootstrap *bootstrap) Run(feed *module.FeedExec, logger log.Logger) []erro
 		modproxy.PluginLibrariesExtension = module.RuntimePluginsType.DeployCommandsPluginExtension
 		modproxy.PluginLibrariesFolder = module.RuntimePluginsType.DeployCommandsPluginFolder
 	}
+	worker.CheckMode = module.RuntimeDeployConfig.CheckMode
+	if worker.CheckMode {
+		Logger.Warn("Check mode: no remote command is executed and no file is transferred")
+	}
 	Logger.Info("Starting Feed execution ...")
-	execErrList := worker.ExecuteFeed(connectionConfig, defaults.ConfigPattern{
+	// Connection handlers and configurations are provided by the hosts sessions
+	execErrList := worker.ExecuteFeed(module.ConnectionConfig{}, defaults.ConfigPattern{
 		Config:     module.RuntimeDeployConfig,
 		Type:       module.RuntimeDeployType,
 		Net:        module.RuntimeNetworkType,
 		Plugins:    module.RuntimePluginsType,
 		Envs:       envs,
 		HostGroups: hosts,
-		Vars:       vars,
+		Vars:       defaults.MergeVars(vars, overrides.ExtraVars),
 	}, feed, sessionsMap, logger)
 	if len(execErrList) > 0 {
 		errorsList = append(errorsList, execErrList...)
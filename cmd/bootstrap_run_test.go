@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Step runnable recording the hosts it runs on
+type hostsRecorder struct {
+	sync.Mutex
+	hosts []string
+}
+
+type recordingRunnable struct {
+	uuid     string
+	host     defaults.HostValue
+	recorder *hostsRecorder
+}
+
+var recordingCount int = 0
+
+func (runnable *recordingRunnable) Run() error {
+	runnable.recorder.Lock()
+	defer runnable.recorder.Unlock()
+	runnable.recorder.hosts = append(runnable.recorder.hosts, runnable.host.Name)
+	return nil
+}
+
+func (runnable *recordingRunnable) Stop() error     { return nil }
+func (runnable *recordingRunnable) Kill() error     { return nil }
+func (runnable *recordingRunnable) Pause() error    { return nil }
+func (runnable *recordingRunnable) Resume() error   { return nil }
+func (runnable *recordingRunnable) IsRunning() bool { return false }
+func (runnable *recordingRunnable) IsPaused() bool  { return false }
+func (runnable *recordingRunnable) UUID() string    { return runnable.uuid }
+
+func (runnable *recordingRunnable) Clone() threads.StepRunnable {
+	recordingCount++
+	return &recordingRunnable{
+		uuid:     fmt.Sprintf("recording-%v", recordingCount),
+		recorder: runnable.recorder,
+	}
+}
+
+func (runnable *recordingRunnable) SetClient(client generic.NetworkClient)  {}
+func (runnable *recordingRunnable) SetHost(host defaults.HostValue)         { runnable.host = host }
+func (runnable *recordingRunnable) SetSession(session module.Session)       {}
+func (runnable *recordingRunnable) SetConfig(config defaults.ConfigPattern) {}
+
+func (runnable *recordingRunnable) Equals(r threads.StepRunnable) bool {
+	return r != nil && r.UUID() == runnable.uuid
+}
+
+func setUpRunTest(t *testing.T) {
+	var deployConfig *module.DeployConfig = module.RuntimeDeployConfig
+	var deployType *module.DeployType = module.RuntimeDeployType
+	var netType *module.NetProtocolType = module.RuntimeNetworkType
+	var pluginsType *module.PluginsConfig = module.RuntimePluginsType
+	var logger log.Logger = Logger
+	module.RuntimeDeployConfig = &module.DeployConfig{
+		ConfigDir:  t.TempDir(),
+		ConfigLang: module.YAML_DESCRIPTOR,
+		MaxThreads: 2,
+	}
+	module.RuntimeDeployType = &module.DeployType{}
+	module.RuntimeNetworkType = &module.NetProtocolType{
+		NetProtocol: module.NET_PROTOCOL_LOCAL,
+		UserName:    "deploy",
+		Password:    "secret",
+	}
+	module.RuntimePluginsType = &module.PluginsConfig{}
+	Logger = log.NewLogger("test", log.ERROR)
+	t.Cleanup(func() {
+		module.RuntimeDeployConfig = deployConfig
+		module.RuntimeDeployType = deployType
+		module.RuntimeNetworkType = netType
+		module.RuntimePluginsType = pluginsType
+		Logger = logger
+	})
+}
+
+func TestRunWithFailsOnlyHostsWithInvalidConnection(t *testing.T) {
+	setUpRunTest(t)
+	var recorder *hostsRecorder = &hostsRecorder{hosts: make([]string, 0)}
+	var groups []defaults.HostGroups = []defaults.HostGroups{
+		{
+			Name: "fleet",
+			Hosts: []defaults.HostValue{
+				{Name: "web-01", IpAddress: "127.0.0.1"},
+				// No connection handler exists for the protocol
+				{Name: "web-02", IpAddress: "127.0.0.2", Connection: &defaults.ConnectionValue{Protocol: "TELNET"}},
+				{Name: "web-03", IpAddress: "127.0.0.3"},
+			},
+		},
+	}
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "fleet",
+		HostGroup: "fleet",
+		Steps: []*module.Step{
+			{Name: "record", StepType: "test", StepData: &recordingRunnable{recorder: recorder}},
+		},
+	}
+	var errs []error = (&bootstrap{}).RunWith(feed, RunOverrides{HostGroups: groups, Vars: []defaults.NameValue{}}, log.NewLogger("test", log.ERROR))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "1 host(s) failed") {
+		t.Fatalf("Expected a single failed host, got %v", errs)
+	}
+	sort.Strings(recorder.hosts)
+	if strings.Join(recorder.hosts, ",") != "web-01,web-03" {
+		t.Fatalf("Expected the step on web-01 and web-03, got %v", recorder.hosts)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/hellgate75/go-tcp-client/client/proxy"
@@ -12,6 +13,7 @@ import (
 	
 	"github.com/hellgate75/go-tcp-common/io"
 	"github.com/hellgate75/go-deploy/net"
+	"github.com/hellgate75/go-deploy/types/defaults"
 	"github.com/hellgate75/go-deploy/types/module"
 )
 
@@ -28,9 +30,35 @@ var (
 	format    string = ""
 	env       string = ""
 	readTimeout int64 = 0
+	checkMode bool    = false
+	limit     string  = ""
+	extraVars extraVarsFlag = extraVarsFlag{}
 	fs        *flag.FlagSet
 )
 
+// Repeatable command line extra variables, in name=value format
+type extraVarsFlag []defaults.NameValue
+
+func (ev *extraVarsFlag) String() string {
+	var pairs []string = make([]string, 0)
+	for _, variable := range *ev {
+		pairs = append(pairs, variable.Name+"="+variable.Value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (ev *extraVarsFlag) Set(value string) error {
+	var idx int = strings.Index(value, "=")
+	if idx <= 0 {
+		return errors.New(fmt.Sprintf("Invalid extra variable %s, expected format is name=value", value))
+	}
+	*ev = append(*ev, defaults.NameValue{
+		Name:  strings.TrimSpace(value[:idx]),
+		Value: value[idx+1:],
+	})
+	return nil
+}
+
 const (
 	Banner string = `
     ###   ###     ###   #### ###   #      ###  #   #
@@ -60,6 +88,9 @@ func init() {
 	fs.StringVar(&format, "language", "", "Config File Language (YAML, XML or JSON), by default AUTO-DETECT on files etension")
 	fs.Int64Var(&readTimeout, "readTimeout", 5, "TCP Client Message Read timeout in seconds, used to keep listening for answer from clients")
 	fs.StringVar(&env, "env", "", "configuration file env suffix (no default value), it will be used to seek for files")
+	fs.StringVar(&limit, "limit", "", "Hosts pattern restricting the feed hosts for the run (e.g.: web-01,db-*)")
+	fs.Var(&extraVars, "e", "Extra variable in name=value format, overriding global, host group and host variables (repeatable)")
+	fs.BoolVar(&checkMode, "check", false, "Dry run, it reports the per host plan without executing remote commands or transferring files")
 	fs.StringVar(&proxy.PluginLibrariesFolder, "client-plugins-folder", proxy.PluginLibrariesFolder, "Folder where seek for client(s) plugin(s) library [Linux Only]")
 	fs.StringVar(&proxy.PluginLibrariesExtension, "client-plugins-extension", proxy.PluginLibrariesExtension, "File extension for client(s) plugin libraries [Linux Only]")
 	fs.BoolVar(&proxy.UsePlugins, "use-client-plugins", proxy.UsePlugins, "Enable/disable client(s) plugins [true|false] [Linux Only]")
@@ -89,8 +120,11 @@ func Usage() {
 
 // Get(s) the given target file for loading the Feed
 func GetTarget() string {
-	if len(os.Args) == 2 && os.Args[0][0:1] != "-" {
-		return os.Args[0]
+	if fs.Parsed() && fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	if len(os.Args) == 2 && (os.Args[1] == "-" || os.Args[1][0:1] != "-") {
+		return os.Args[1]
 	} else if len(os.Args) == 3 && os.Args[0][0:1] != "-" {
 		return os.Args[1]
 	} else if len(os.Args) > 3 && os.Args[len(os.Args)-2][0:1] != "-" {
@@ -99,6 +133,11 @@ func GetTarget() string {
 	return ""
 }
 
+// Get(s) the extra variables given on the command line
+func GetExtraVars() []defaults.NameValue {
+	return append([]defaults.NameValue{}, extraVars...)
+}
+
 // Parse Command line arguments
 func ParseArguments() (*module.DeployConfig, error) {
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -117,5 +156,7 @@ func ParseArguments() (*module.DeployConfig, error) {
 		ConfigLang:   module.DescriptorTypeValue(format),
 		EnvSelector:  env,
 		ReadTimeout: readTimeout,
+		CheckMode:   checkMode,
+		Limit:       limit,
 	}, nil
 }
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-tcp-common/log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger that keeps a copy of the messages of a single run, forwarding them to the system logger
+type runLogger struct {
+	log.Logger
+	sync.Mutex
+	lines []string
+}
+
+func (logger *runLogger) append(level string, message string) {
+	logger.Lock()
+	defer logger.Unlock()
+	logger.lines = append(logger.lines, fmt.Sprintf("%s [%s] %s", time.Now().Format("2006-01-02 15:04:05"), level, strings.TrimRight(message, "\n")))
+}
+
+func (logger *runLogger) getLines() []string {
+	logger.Lock()
+	defer logger.Unlock()
+	var lines []string = make([]string, len(logger.lines))
+	copy(lines, logger.lines)
+	return lines
+}
+
+func (logger *runLogger) Debug(in ...interface{}) {
+	logger.append("DEBUG", fmt.Sprint(in...))
+	logger.Logger.Debug(in...)
+}
+
+func (logger *runLogger) Debugf(format string, in ...interface{}) {
+	logger.append("DEBUG", fmt.Sprintf(format, in...))
+	logger.Logger.Debugf(format, in...)
+}
+
+func (logger *runLogger) Info(in ...interface{}) {
+	logger.append("INFO", fmt.Sprint(in...))
+	logger.Logger.Info(in...)
+}
+
+func (logger *runLogger) Infof(format string, in ...interface{}) {
+	logger.append("INFO", fmt.Sprintf(format, in...))
+	logger.Logger.Infof(format, in...)
+}
+
+func (logger *runLogger) Warn(in ...interface{}) {
+	logger.append("WARN", fmt.Sprint(in...))
+	logger.Logger.Warn(in...)
+}
+
+func (logger *runLogger) Warnf(format string, in ...interface{}) {
+	logger.append("WARN", fmt.Sprintf(format, in...))
+	logger.Logger.Warnf(format, in...)
+}
+
+func (logger *runLogger) Error(in ...interface{}) {
+	logger.append("ERROR", fmt.Sprint(in...))
+	logger.Logger.Error(in...)
+}
+
+func (logger *runLogger) Errorf(format string, in ...interface{}) {
+	logger.append("ERROR", fmt.Sprintf(format, in...))
+	logger.Logger.Errorf(format, in...)
+}
+
+func (logger *runLogger) Successf(format string, in ...interface{}) {
+	logger.append("SUCCESS", fmt.Sprintf(format, in...))
+	logger.Logger.Successf(format, in...)
+}
+
+func (logger *runLogger) Failuref(format string, in ...interface{}) {
+	logger.append("FAILURE", fmt.Sprintf(format, in...))
+	logger.Logger.Failuref(format, in...)
+}
+
+func newRunLogger(logger log.Logger) *runLogger {
+	return &runLogger{
+		Logger: logger,
+		lines:  make([]string, 0),
+	}
+}
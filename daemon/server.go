@@ -0,0 +1,310 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-tcp-common/log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var Logger log.Logger = nil
+
+const (
+	// Run waiting for the previous runs to complete
+	RUN_QUEUED string = "queued"
+	// Run in progress
+	RUN_RUNNING string = "running"
+	// Run completed without errors
+	RUN_SUCCESS string = "success"
+	// Run completed with errors
+	RUN_FAILED string = "failed"
+	// Default listen address for the daemon trigger API
+	DEFAULT_LISTEN_ADDRESS string = "127.0.0.1:8090"
+	// Prefix of listen addresses that identifies a Unix socket path
+	UNIX_SOCKET_PREFIX string = "unix:"
+	// Maximum number of completed runs kept for status and logs requests
+	MAX_RETAINED_RUNS int = 100
+)
+
+// Function that runs the given feed with the override variables, logging through the given logger
+type RunFunc func(feed string, vars []defaults.NameValue, logger log.Logger) error
+
+// Trigger request body
+type RunRequest struct {
+	Feed string            `json:"feed,omitempty"`
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Run status, as returned by the trigger API
+type Run struct {
+	Id     string            `json:"id"`
+	Feed   string            `json:"feed"`
+	Vars   map[string]string `json:"vars,omitempty"`
+	Status string            `json:"status"`
+	Queued time.Time         `json:"queued"`
+	Start  *time.Time        `json:"start,omitempty"`
+	End    *time.Time        `json:"end,omitempty"`
+	Errors []string          `json:"errors,omitempty"`
+	logger *runLogger
+}
+
+// Daemon server, it queues triggered runs and executes them one at a time
+type Server struct {
+	sync.RWMutex
+	defaultFeed string
+	runFunc     RunFunc
+	runs        map[string]*Run
+	order       []string
+	queue       chan *Run
+}
+
+// Queues a run of the requested feed, it retrieves nil when the queue is full
+func (server *Server) trigger(request RunRequest) *Run {
+	var feed string = request.Feed
+	if feed == "" {
+		feed = server.defaultFeed
+	}
+	var run *Run = &Run{
+		Id:     module.NewSessionId(),
+		Feed:   feed,
+		Vars:   request.Vars,
+		Status: RUN_QUEUED,
+		Queued: time.Now(),
+		logger: newRunLogger(Logger),
+	}
+	server.Lock()
+	defer server.Unlock()
+	select {
+	case server.queue <- run:
+	default:
+		// Queue full
+		return nil
+	}
+	server.runs[run.Id] = run
+	server.order = append(server.order, run.Id)
+	server.purge()
+	return run
+}
+
+// Removes the oldest completed runs over the retained runs limit, it requires the write lock
+func (server *Server) purge() {
+	for len(server.order) > MAX_RETAINED_RUNS {
+		var removed bool = false
+		for idx, id := range server.order {
+			if run := server.runs[id]; run.Status != RUN_QUEUED && run.Status != RUN_RUNNING {
+				delete(server.runs, id)
+				server.order = append(server.order[:idx], server.order[idx+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return
+		}
+	}
+}
+
+func (server *Server) execute(run *Run) {
+	var start time.Time = time.Now()
+	server.Lock()
+	run.Status = RUN_RUNNING
+	run.Start = &start
+	server.Unlock()
+	var vars []defaults.NameValue = make([]defaults.NameValue, 0)
+	var names []string = make([]string, 0)
+	for name, _ := range run.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vars = append(vars, defaults.NameValue{
+			Name:  name,
+			Value: run.Vars[name],
+		})
+	}
+	run.logger.Warnf("Starting run %s of feed: %s", run.Id, run.Feed)
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New(fmt.Sprintf("%v", r))
+			}
+		}()
+		err = server.runFunc(run.Feed, vars, run.logger)
+	}()
+	var end time.Time = time.Now()
+	server.Lock()
+	run.End = &end
+	if err != nil {
+		run.Status = RUN_FAILED
+		run.Errors = []string{err.Error()}
+	} else {
+		run.Status = RUN_SUCCESS
+	}
+	server.Unlock()
+	run.logger.Warnf("Run %s completed with status: %s, elapsed time: %s", run.Id, run.Status, end.Sub(start).String())
+}
+
+func (server *Server) getRun(id string) (Run, []string, bool) {
+	server.RLock()
+	defer server.RUnlock()
+	if run, ok := server.runs[id]; ok {
+		return *run, run.logger.getLines(), true
+	}
+	return Run{}, nil, false
+}
+
+func (server *Server) listRuns() []Run {
+	server.RLock()
+	defer server.RUnlock()
+	var runs []Run = make([]Run, 0)
+	for _, id := range server.order {
+		runs = append(runs, *server.runs[id])
+	}
+	return runs
+}
+
+func writeJson(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, map[string]string{"error": message})
+}
+
+func (server *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeJson(w, http.StatusOK, server.listRuns())
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed: "+r.Method)
+		return
+	}
+	var request RunRequest = RunRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid run request: "+err.Error())
+			return
+		}
+	}
+	if request.Feed == "" && server.defaultFeed == "" {
+		writeError(w, http.StatusBadRequest, "Missing feed name")
+		return
+	}
+	run := server.trigger(request)
+	if run == nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("Too many queued runs, maximum: %v", cap(server.queue)))
+		return
+	}
+	Logger.Infof("Queued run %s for feed: %s", run.Id, run.Feed)
+	writeJson(w, http.StatusAccepted, map[string]string{"id": run.Id, "status": RUN_QUEUED})
+}
+
+func (server *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed: "+r.Method)
+		return
+	}
+	var path string = strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs/"), "/")
+	var parts []string = strings.Split(path, "/")
+	run, lines, ok := server.getRun(parts[0])
+	if !ok {
+		writeError(w, http.StatusNotFound, "Run not found: "+parts[0])
+		return
+	}
+	if len(parts) == 1 {
+		writeJson(w, http.StatusOK, run)
+	} else if len(parts) == 2 && parts[1] == "logs" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	} else {
+		writeError(w, http.StatusNotFound, "Resource not found: "+r.URL.Path)
+	}
+}
+
+// Retrieves the trigger API handler:
+// POST /runs triggers a run, GET /runs lists the runs, GET /runs/{id} retrieves a run status and
+// GET /runs/{id}/logs retrieves a run logs
+func (server *Server) Handler() http.Handler {
+	var mux *http.ServeMux = http.NewServeMux()
+	mux.HandleFunc("/runs", server.handleRuns)
+	mux.HandleFunc("/runs/", server.handleRun)
+	return mux
+}
+
+// Starts executing queued runs, one at a time, until the stop channel is closed
+func (server *Server) Start(stop <-chan struct{}) {
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case run := <-server.queue:
+				server.execute(run)
+			}
+		}
+	}()
+}
+
+// Listens on the given address, as host:port or as unix:<socket path>, serving the trigger API until the stop
+// channel is closed
+func (server *Server) ListenAndServe(address string, stop <-chan struct{}) error {
+	if address == "" {
+		address = DEFAULT_LISTEN_ADDRESS
+	}
+	var listener net.Listener
+	var err error
+	if strings.HasPrefix(address, UNIX_SOCKET_PREFIX) {
+		var socketPath string = strings.TrimPrefix(strings.TrimPrefix(address, UNIX_SOCKET_PREFIX), "//")
+		os.Remove(socketPath)
+		listener, err = net.Listen("unix", socketPath)
+		if err == nil {
+			defer os.Remove(socketPath)
+		}
+	} else {
+		listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	server.Start(stop)
+	var httpServer *http.Server = &http.Server{
+		Handler: server.Handler(),
+	}
+	go func() {
+		<-stop
+		httpServer.Close()
+	}()
+	Logger.Warnf("Deploy daemon listening on: %s", address)
+	err = httpServer.Serve(listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Creates a new daemon Server, running the default feed when triggers don't specify a feed name
+func NewServer(defaultFeed string, runFunc RunFunc) *Server {
+	return &Server{
+		defaultFeed: defaultFeed,
+		runFunc:     runFunc,
+		runs:        make(map[string]*Run),
+		order:       make([]string, 0),
+		queue:       make(chan *Run, MAX_RETAINED_RUNS),
+	}
+}
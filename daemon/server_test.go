@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"encoding/json"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-tcp-common/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setUpServerTest(t *testing.T) {
+	var logger log.Logger = Logger
+	Logger = log.NewLogger("test", log.ERROR)
+	t.Cleanup(func() {
+		Logger = logger
+	})
+}
+
+func serve(server *Server, method string, path string, body string) *httptest.ResponseRecorder {
+	var recorder *httptest.ResponseRecorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, value interface{}) {
+	if err := json.Unmarshal(recorder.Body.Bytes(), value); err != nil {
+		t.Fatalf("Invalid response %q: %v", recorder.Body.String(), err)
+	}
+}
+
+func TestTriggerQueuesRun(t *testing.T) {
+	setUpServerTest(t)
+	var server *Server = NewServer("default.yaml", nil)
+	var recorder *httptest.ResponseRecorder = serve(server, http.MethodPost, "/runs", `{"feed":"app.yaml","vars":{"version":"1.2"}}`)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %v: %s", recorder.Code, recorder.Body.String())
+	}
+	var accepted map[string]string = make(map[string]string)
+	decode(t, recorder, &accepted)
+	if accepted["id"] == "" || accepted["status"] != RUN_QUEUED {
+		t.Fatalf("Unexpected trigger response: %v", accepted)
+	}
+	recorder = serve(server, http.MethodGet, "/runs/"+accepted["id"], "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %v", recorder.Code)
+	}
+	var run Run = Run{}
+	decode(t, recorder, &run)
+	if run.Feed != "app.yaml" || run.Status != RUN_QUEUED || run.Vars["version"] != "1.2" {
+		t.Fatalf("Unexpected run: %+v", run)
+	}
+	recorder = serve(server, http.MethodPost, "/runs", "")
+	var defaultRun map[string]string = make(map[string]string)
+	decode(t, recorder, &defaultRun)
+	var runs []Run = make([]Run, 0)
+	decode(t, serve(server, http.MethodGet, "/runs", ""), &runs)
+	if len(runs) != 2 || runs[0].Id != accepted["id"] || runs[1].Feed != "default.yaml" {
+		t.Fatalf("Unexpected runs list: %+v", runs)
+	}
+}
+
+func TestTriggerRejectsInvalidRequests(t *testing.T) {
+	setUpServerTest(t)
+	var server *Server = NewServer("", nil)
+	for _, test := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/runs", "", http.StatusBadRequest},
+		{http.MethodPost, "/runs", "{", http.StatusBadRequest},
+		{http.MethodPut, "/runs", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/runs/123", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/runs/123", "", http.StatusNotFound},
+	} {
+		if recorder := serve(server, test.method, test.path, test.body); recorder.Code != test.status {
+			t.Errorf("%s %s: expected status %v, got %v", test.method, test.path, test.status, recorder.Code)
+		}
+	}
+}
+
+func TestTriggerAnswersUnavailableWhenQueueIsFull(t *testing.T) {
+	setUpServerTest(t)
+	var server *Server = NewServer("app.yaml", nil)
+	for index := 0; index < cap(server.queue); index++ {
+		if recorder := serve(server, http.MethodPost, "/runs", ""); recorder.Code != http.StatusAccepted {
+			t.Fatalf("Run %v: expected status 202, got %v", index, recorder.Code)
+		}
+	}
+	var done chan *httptest.ResponseRecorder = make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve(server, http.MethodPost, "/runs", "")
+	}()
+	select {
+	case recorder := <-done:
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %v", recorder.Code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Trigger blocked on the full queue")
+	}
+	var runs []Run = make([]Run, 0)
+	decode(t, serve(server, http.MethodGet, "/runs", ""), &runs)
+	if len(runs) != cap(server.queue) {
+		t.Fatalf("Expected %v runs, got %v", cap(server.queue), len(runs))
+	}
+}
+
+func TestStartExecutesQueuedRuns(t *testing.T) {
+	setUpServerTest(t)
+	var executed chan []defaults.NameValue = make(chan []defaults.NameValue, 1)
+	var server *Server = NewServer("", func(feed string, vars []defaults.NameValue, logger log.Logger) error {
+		logger.Infof("Running feed: %s", feed)
+		executed <- vars
+		return nil
+	})
+	var stop chan struct{} = make(chan struct{})
+	defer close(stop)
+	server.Start(stop)
+	var accepted map[string]string = make(map[string]string)
+	decode(t, serve(server, http.MethodPost, "/runs", `{"feed":"app.yaml","vars":{"b":"2","a":"1"}}`), &accepted)
+	select {
+	case vars := <-executed:
+		if len(vars) != 2 || vars[0].Name != "a" || vars[1].Name != "b" {
+			t.Fatalf("Unexpected run variables: %v", vars)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run not executed")
+	}
+	var run Run = Run{}
+	for index := 0; index < 100; index++ {
+		decode(t, serve(server, http.MethodGet, "/runs/"+accepted["id"], ""), &run)
+		if run.Status == RUN_SUCCESS {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if run.Status != RUN_SUCCESS || run.Start == nil || run.End == nil {
+		t.Fatalf("Expected a completed run, got %+v", run)
+	}
+	var recorder *httptest.ResponseRecorder = serve(server, http.MethodGet, "/runs/"+accepted["id"]+"/logs", "")
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "Running feed: app.yaml") {
+		t.Fatalf("Unexpected run logs: %v %s", recorder.Code, recorder.Body.String())
+	}
+}
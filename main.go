@@ -13,11 +13,16 @@ import (
 	ngen "github.com/hellgate75/go-deploy/net/generic"
 	modproxy "github.com/hellgate75/go-deploy/modules/proxy"
 	"github.com/hellgate75/go-deploy/cmd"
+	"github.com/hellgate75/go-deploy/daemon"
 	"github.com/hellgate75/go-tcp-common/io"
 	"github.com/hellgate75/go-deploy/modules"
 	"github.com/hellgate75/go-deploy/net"
+	"github.com/hellgate75/go-deploy/net/sshconfig"
+	"github.com/hellgate75/go-deploy/types/defaults"
 	"github.com/hellgate75/go-deploy/types/generic"
+	"github.com/hellgate75/go-deploy/types/inventory"
 	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/schedule"
 	"github.com/hellgate75/go-deploy/utils"
 )
 
@@ -32,7 +37,6 @@ func init() {
 	}()
 	Logger = log.NewLogger("go-deploy", log.INFO)
 	setupLogger()
-	printInfo()
 }
 
 func setupLogger() {
@@ -42,18 +46,28 @@ func setupLogger() {
 			os.Exit(1)
 		}
 	}()
-	module.Logger = Logger
-	generic.Logger = Logger
-	modules.Logger = Logger
-	cmd.Logger = Logger
-	ngen.Logger = Logger
-	modproxy.Logger = Logger
-	net.Logger = Logger
+	useLogger(Logger)
+	daemon.Logger = Logger
 	Logger.Trace("Init ...")
 	worker.Logger.AffiliateTo(Logger)
 	
 }
 
+// Sets the logger used by the deploy procedure packages
+func useLogger(logger log.Logger) {
+	Logger = logger
+	module.Logger = logger
+	generic.Logger = logger
+	modules.Logger = logger
+	cmd.Logger = logger
+	ngen.Logger = logger
+	sshconfig.Logger = logger
+	modproxy.Logger = logger
+	net.Logger = logger
+	schedule.Logger = logger
+	inventory.Logger = logger
+}
+
 func printInfo() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -86,139 +100,217 @@ func main() {
 	}()
 	if cmd.RequiresHelp() {
 		help = true
+		printInfo()
 		color.Yellow.Println("Help required")
 	} else {
-		Logger.Infof("Logger initial Verbosity : %v", Logger.GetVerbosity())
 		Logger.Trace("Main ...")
 		config, err := cmd.ParseArguments()
+		var initialVerbosity log.LogLevel = Logger.GetVerbosity()
 		if config.LogVerbosity != "" && config.LogVerbosity != string(Logger.GetVerbosity()) {
 			Logger.SetVerbosity(log.VerbosityLevelFromString(config.LogVerbosity))
 			//worker.Logger.SetVerbosity(clientlog.VerbosityLevelFromString(config.LogVerbosity))
-			Logger.Infof("Logger Verbosity Setted up to : %v", Logger.GetVerbosity())
 		}
 		if err != nil {
+			printInfo()
 			Logger.Errorf("Error: %v", err)
 			cmd.Usage()
 		} else {
 			var target string = cmd.GetTarget()
 			if target == "" {
+				printInfo()
 				cmd.Usage()
 				panic("Error: No target defined")
 			} else {
 				var boostrap cmd.Bootstrap = cmd.NewBootStrap()
-				config.WorkDir = utils.FixFolder(config.WorkDir, io.GetCurrentFolder(), "")
-				config.ConfigDir = utils.FixFolder(config.ConfigDir, config.WorkDir, cmd.DEPLOY_CONFIG_FILE_NAME)
-				
-				errB := boostrap.Init(config.ConfigDir, config.EnvSelector, config.ConfigLang, Logger)
-				Logger.Debugf("Errors during config init: %v", len(errB))
-				if len(errB) > 0 {
-					var errors string = ""
-					for _, errX := range errB {
-						prefix := ""
-						if len(errors) > 0 {
-							prefix = "\n"
-						}
-						errors += prefix + errX.Error()
-					}
-					Logger.Errorf("Error: During config files initialization -> <%v>...", errors)
-					os.Exit(1)
+				dc, dt, errC := loadConfiguration(boostrap, config)
+				if errC == nil && dt.DeploymentType == module.PIPE_SOURCE {
+					// The standard output only carries the documents results records
+					useLogger(newPipeLogger(Logger))
 				}
-				var dc *module.DeployConfig = boostrap.GetDeployConfig()
-				if dc == nil {
-					dc = &module.DeployConfig{}
+				printInfo()
+				Logger.Infof("Logger initial Verbosity : %v", initialVerbosity)
+				if initialVerbosity != Logger.GetVerbosity() {
+					Logger.Infof("Logger Verbosity Setted up to : %v", Logger.GetVerbosity())
 				}
-				if dc.DeployName != "" {
-					config.DeployName = dc.DeployName
-				}
-				dc = dc.Merge(config)
-				dc.WorkDir = utils.FixFolder(dc.WorkDir, io.GetCurrentFolder(), "")
-				dc.ConfigDir = utils.FixFolder(dc.ConfigDir, dc.WorkDir, cmd.DEPLOY_CONFIG_FILE_NAME)
-				if dc.LogVerbosity != "" && dc.LogVerbosity != string(Logger.GetVerbosity()) {
-					Logger.SetVerbosity(log.VerbosityLevelFromString(dc.LogVerbosity))
-					worker.Logger.SetVerbosity(clientlog.VerbosityLevelFromString(dc.LogVerbosity))
-					Logger.Debugf("Logger Verbosity Setted up to : %v", Logger.GetVerbosity())
-				}
-				module.RuntimeDeployConfig = dc
-				clicommon.DEFAULT_TIMEOUT = time.Duration(dc.ReadTimeout) * time.Second
-				errB = boostrap.Load(dc.ConfigDir, dc.EnvSelector, dc.ConfigLang, Logger)
-				Logger.Debugf("Errors during config load: %v", len(errB))
-				if len(errB) > 0 {
-					var errors string = ""
-					for _, errX := range errB {
-						prefix := ""
-						if len(errors) > 0 {
-							prefix = "\n"
-						}
-						errors += prefix + "- " + errX.Error()
-					}
-					Logger.Errorf("Error: During config files load -> <%v>...", errors)
+				if errC != nil {
+					Logger.Errorf("Error: %s", errC.Error())
 					os.Exit(1)
 				}
-				var dt *module.DeployType = boostrap.GetDeployType()
-				if dt == nil {
-					dt = &module.DeployType{}
-				}
-				dt = boostrap.GetDefaultDeployType().Merge(dt)
-				module.RuntimeDeployType = dt
-				var nt *module.NetProtocolType = boostrap.GetNetType()
-				if nt == nil {
-					nt = &module.NetProtocolType{}
+				if dt.StrategyType == module.PERIODIC_DEPLOYMENT {
+					err = runPeriodicDeployment(config, target, dt.Scheduled)
+				} else if dt.StrategyType == module.CONTINUOUS_DEPLOYMENT {
+					err = runContinuousDeployment(config, target)
+				} else if dt.StrategyType == module.ON_DEMAND_DEPLOYMENT {
+					err = runOnDemandDeployment(boostrap, dc, dt, target)
+				} else {
+					err = runDeployment(boostrap, dc, dt, target, cmd.GetExtraVars())
 				}
-				nt = boostrap.GetDefaultNetType().Merge(nt)
-				module.RuntimeNetworkType = nt
-				
-				var pc *module.PluginsConfig = boostrap.GetPluginsType()
-				if pc == nil {
-					pc = &module.PluginsConfig{}
+				if err != nil {
+					panic(err.Error())
 				}
-				pc = boostrap.GetDefaultPluginsType().Merge(pc)
-				module.RuntimePluginsType = pc
-				
-				Logger.Debugf("Configuration Summary: \nDeploy Config: %v\nDeployType: %v\nNetType: %v\n", dc.String(), dt.String(), nt.String())
-				if dt.DeploymentType == module.FILE_SOURCE {
-					var filePath string = dc.WorkDir + io.GetPathSeparator() + target
-					Logger.Warnf("Loaging Main Feed at path: %s\n", filePath)
-					var feed generic.IFeed = generic.NewFeed("default")
-					err = feed.Load(filePath)
-					if err != nil {
-						Logger.Errorf("Error trying to load Feed for file: %s -> Details: \n%s", filePath, err.Error())
-						os.Exit(1)
-					}
-					feedEx, errValList := feed.Validate()
-					if len(errValList) > 0 {
-						var errors string = ""
-						for _, errX := range errValList {
-							prefix := ""
-							if len(errors) > 0 {
-								prefix = "\n"
-							}
-							errors += prefix + "- " + errX.Error()
-						}
-						panic(fmt.Sprintf("Error trying to validate Feed for file: %s -> Details: \n%s", filePath, errors))
-					}
-					if len(feedEx.Steps) > 0 {
-						Logger.Debugf("Reading file: %s, discovered %s main steps!!", filePath, strconv.Itoa(len(feedEx.Steps)))
-						errExList := boostrap.Run(feedEx, Logger)
-						if len(errExList) > 0 {
-							var errors string = ""
-							for _, errX := range errExList {
-								prefix := ""
-								if len(errors) > 0 {
-									prefix = "\n"
-								}
-								errors += prefix + errX.Error()
-							}
-							panic(fmt.Sprintf("Error: During deploy execution -> <%v>...", errors))
-						}
-					} else {
-						Logger.Warnf("Unable to find any command in the given file: %s", filePath)
-						Logger.Warn("Nothing to do here!!")
-					}
-					Logger.Warn("Deploy procedure complete!!")
-				} else {
-					Logger.Warnf("Feature %v NOT IMPLEMENTED yet!!", dt.DeploymentType)
+			}
+		}
+	}
+}
+
+// Runs the deploy, loading the feed(s) from the deploy type deployment source, and merging the given extra variables
+// over the configured ones
+func runDeployment(boostrap cmd.Bootstrap, dc *module.DeployConfig, dt *module.DeployType, target string, extraVars []defaults.NameValue) error {
+	var err error
+	if dt.DeploymentType == module.FILE_SOURCE {
+		var filePath string = dc.WorkDir + io.GetPathSeparator() + target
+		err = runFeed(boostrap, generic.NewFileFeedSource(), filePath, cmd.RunOverrides{ExtraVars: extraVars})
+	} else if dt.DeploymentType == module.HTTP_SOURCE {
+		source, location, errS := newHttpFeedSource(dc, dt, target)
+		if errS != nil {
+			return errS
+		}
+		err = runFeed(boostrap, source, location, cmd.RunOverrides{ExtraVars: extraVars})
+	} else if dt.DeploymentType == module.PIPE_SOURCE {
+		err = runPipeFeeds(boostrap, dc, target)
+	} else if dt.DeploymentType == module.REST_SOURCE {
+		envelope, location, errS := fetchRestFeedEnvelope(dc, dt, target)
+		if errS != nil {
+			return errS
+		}
+		err = executeFeed(boostrap, &envelope.Feed, location, cmd.RunOverrides{
+			HostGroups: envelope.HostGroups,
+			Vars:       envelope.Vars,
+			ExtraVars:  extraVars,
+		})
+	} else {
+		Logger.Warnf("Feature %v NOT IMPLEMENTED yet!!", dt.DeploymentType)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	Logger.Warn("Deploy procedure complete!!")
+	return nil
+}
+
+// Loads and merges deploy config, deploy type, network type and plugins configuration, saving them in the Runtime
+// module variables
+func loadConfiguration(boostrap cmd.Bootstrap, config *module.DeployConfig) (*module.DeployConfig, *module.DeployType, error) {
+	config.WorkDir = utils.FixFolder(config.WorkDir, io.GetCurrentFolder(), "")
+	config.ConfigDir = utils.FixFolder(config.ConfigDir, config.WorkDir, cmd.DEPLOY_CONFIG_FILE_NAME)
+
+	errB := boostrap.Init(config.ConfigDir, config.EnvSelector, config.ConfigLang, Logger)
+	Logger.Debugf("Errors during config init: %v", len(errB))
+	if len(errB) > 0 {
+		var errors string = ""
+		for _, errX := range errB {
+			prefix := ""
+			if len(errors) > 0 {
+				prefix = "\n"
+			}
+			errors += prefix + errX.Error()
+		}
+		return nil, nil, fmt.Errorf("During config files initialization -> <%v>...", errors)
+	}
+	var dc *module.DeployConfig = boostrap.GetDeployConfig()
+	if dc == nil {
+		dc = &module.DeployConfig{}
+	}
+	if dc.DeployName != "" {
+		config.DeployName = dc.DeployName
+	}
+	dc = dc.Merge(config)
+	dc.WorkDir = utils.FixFolder(dc.WorkDir, io.GetCurrentFolder(), "")
+	dc.ConfigDir = utils.FixFolder(dc.ConfigDir, dc.WorkDir, cmd.DEPLOY_CONFIG_FILE_NAME)
+	if dc.LogVerbosity != "" && dc.LogVerbosity != string(Logger.GetVerbosity()) {
+		Logger.SetVerbosity(log.VerbosityLevelFromString(dc.LogVerbosity))
+		worker.Logger.SetVerbosity(clientlog.VerbosityLevelFromString(dc.LogVerbosity))
+		Logger.Debugf("Logger Verbosity Setted up to : %v", Logger.GetVerbosity())
+	}
+	module.RuntimeDeployConfig = dc
+	clicommon.DEFAULT_TIMEOUT = time.Duration(dc.ReadTimeout) * time.Second
+	errB = boostrap.Load(dc.ConfigDir, dc.EnvSelector, dc.ConfigLang, Logger)
+	Logger.Debugf("Errors during config load: %v", len(errB))
+	if len(errB) > 0 {
+		var errors string = ""
+		for _, errX := range errB {
+			prefix := ""
+			if len(errors) > 0 {
+				prefix = "\n"
+			}
+			errors += prefix + "- " + errX.Error()
+		}
+		return nil, nil, fmt.Errorf("During config files load -> <%v>...", errors)
+	}
+	var dt *module.DeployType = boostrap.GetDeployType()
+	if dt == nil {
+		dt = &module.DeployType{}
+	}
+	dt = boostrap.GetDefaultDeployType().Merge(dt)
+	module.RuntimeDeployType = dt
+	var nt *module.NetProtocolType = boostrap.GetNetType()
+	if nt == nil {
+		nt = &module.NetProtocolType{}
+	}
+	nt = boostrap.GetDefaultNetType().Merge(nt)
+	module.RuntimeNetworkType = nt
+
+	var pc *module.PluginsConfig = boostrap.GetPluginsType()
+	if pc == nil {
+		pc = &module.PluginsConfig{}
+	}
+	pc = boostrap.GetDefaultPluginsType().Merge(pc)
+	module.RuntimePluginsType = pc
+
+	var ic *module.InventoryConfig = boostrap.GetInventoryConfig()
+	if ic == nil {
+		ic = &module.InventoryConfig{}
+	}
+	module.RuntimeInventoryConfig = ic
+
+	Logger.Debugf("Configuration Summary: \nDeploy Config: %v\nDeployType: %v\nNetType: %v\n", dc.String(), dt.String(), nt.String())
+	return dc, dt, nil
+}
+
+// Loads the main feed from the given source and location, validates it and runs the deploy, using the given run
+// overrides
+func runFeed(boostrap cmd.Bootstrap, source generic.FeedSource, location string, overrides cmd.RunOverrides) error {
+	Logger.Warnf("Loaging Main Feed at path: %s\n", location)
+	var feed generic.IFeed = generic.NewFeed("default")
+	err := feed.LoadFrom(source, location)
+	if err != nil {
+		return fmt.Errorf("Error trying to load Feed for file: %s -> Details: \n%s", location, err.Error())
+	}
+	return executeFeed(boostrap, feed, location, overrides)
+}
+
+// Validates a loaded feed and runs the deploy, using the given run overrides
+func executeFeed(boostrap cmd.Bootstrap, feed generic.IFeed, location string, overrides cmd.RunOverrides) error {
+	feedEx, errValList := feed.Validate()
+	if len(errValList) > 0 {
+		var errors string = ""
+		for _, errX := range errValList {
+			prefix := ""
+			if len(errors) > 0 {
+				prefix = "\n"
+			}
+			errors += prefix + "- " + errX.Error()
+		}
+		return fmt.Errorf("Error trying to validate Feed for file: %s -> Details: \n%s", location, errors)
+	}
+	if len(feedEx.Steps) > 0 {
+		Logger.Debugf("Reading file: %s, discovered %s main steps!!", location, strconv.Itoa(len(feedEx.Steps)))
+		errExList := boostrap.RunWith(feedEx, overrides, Logger)
+		if len(errExList) > 0 {
+			var errors string = ""
+			for _, errX := range errExList {
+				prefix := ""
+				if len(errors) > 0 {
+					prefix = "\n"
 				}
+				errors += prefix + errX.Error()
 			}
+			return fmt.Errorf("Error: During deploy execution -> <%v>...", errors)
 		}
+	} else {
+		Logger.Warnf("Unable to find any command in the given file: %s", location)
+		Logger.Warn("Nothing to do here!!")
 	}
+	return nil
 }
@@ -9,6 +9,8 @@ import (
 	"plugin"
 	"strings"
 	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/net/local"
+	"github.com/hellgate75/go-deploy/net/ssh"
 )
 
 var Logger log.Logger = nil
@@ -20,7 +22,13 @@ var PluginLibrariesFolder string = getDefaultPluginsFolder()
 // Assume this extension name for ;loading the libraries (we hope in future windows will allow plugins)
 var PluginLibrariesExtension = "so"
 
-// Looks up for connection Handler linked to a given client name
+// Name of the built-in SSH client
+const BUILTIN_SSH_CLIENT string = "SSH"
+
+// Name of the built-in local machine client
+const BUILTIN_LOCAL_CLIENT string = "LOCAL"
+
+// Looks up for connection Handler linked to a given client name, plugins are preferred to built-in clients
 func DiscoverConnectionHandler(clientName string) (generic.NewConnectionHandlerFunc, error) {
 	if UsePlugins {
 		Logger.Debugf("client.proxy.GetSender() -> Loading library for command: %s", clientName)
@@ -34,6 +42,14 @@ func DiscoverConnectionHandler(clientName string) (generic.NewConnectionHandlerF
 			return handler, nil
 		}
 	}
+	if strings.ToUpper(clientName) == BUILTIN_SSH_CLIENT {
+		ssh.Logger = Logger
+		return ssh.NewConnectionHandler, nil
+	}
+	if strings.ToUpper(clientName) == BUILTIN_LOCAL_CLIENT {
+		local.Logger = Logger
+		return local.NewConnectionHandler, nil
+	}
 	return proxy.GetConnectionHandlerFactory(clientName)
 }
 
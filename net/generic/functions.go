@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hellgate75/go-tcp-common/log"
+	"github.com/hellgate75/go-deploy/net/sshconfig"
 	"github.com/hellgate75/go-deploy/types/defaults"
 	"github.com/hellgate75/go-deploy/types/module"
 	"github.com/hellgate75/go-tcp-client/common"
@@ -21,24 +22,64 @@ func ConnectHandlerViaConfig(connConfig module.ConnectionConfig, handler Connect
 		hostRef = host.IpAddress
 	}
 	addr := fmt.Sprintf("%s:%s", hostRef, host.Port)
+	var userName string = netConfig.UserName
+	var keyFile string = netConfig.KeyFile
+	var jumpHosts []JumpHost = make([]JumpHost, 0)
+	if connConfig.UseSSHConfig {
+		connection, err := resolveSSHConnection(hostRef, host.Port, netConfig)
+		if err != nil {
+			return nil, err
+		}
+		addr = connection.address
+		userName = connection.userName
+		keyFile = connection.keyFile
+		jumpHosts = connection.jumpHosts
+		if Logger != nil {
+			Logger.Debugf("SSH config: host %s -> address: %s, user: %s, key file: %s, jump hosts: %v", hostRef, addr, userName, keyFile, len(connection.jumpHosts))
+		}
+	}
+	if host.JumpHost != nil {
+		// Hosts file jump hosts are preferred to the SSH config ones
+		jumpHosts = hostJumpHosts(host.JumpHost, netConfig)
+	}
+	for i, jumpHost := range jumpHosts {
+		if jumpHost.KeyFile != "" &&
+			strings.Index(jumpHost.KeyFile, ":") < 0 &&
+			strings.Index(jumpHost.KeyFile, "/") != 0 &&
+			strings.Index(jumpHost.KeyFile, "\\") != 0 {
+			jumpHosts[i].KeyFile = depConfig.WorkDir + string(os.PathSeparator) + jumpHost.KeyFile
+		}
+	}
+	if keyHandler, ok := handler.(HostKeyConnectionHandler); ok {
+		policy, err := hostKeyPolicy(host, netConfig, depConfig)
+		if err != nil {
+			return nil, err
+		}
+		keyHandler.SetHostKeyPolicy(policy)
+	}
+	if jumpHandler, ok := handler.(JumpConnectionHandler); ok {
+		jumpHandler.SetJumpHosts(jumpHosts)
+	} else if len(jumpHosts) > 0 {
+		return nil, errors.New(string(netConfig.NetProtocol) + ": Jump hosts are not supported by the connection handler, required by host: " + hostRef)
+	}
 	if connConfig.UseUserPassword {
-		globalError = handler.ConnectWithPasswd(addr, netConfig.UserName, netConfig.Password)
+		globalError = handler.ConnectWithPasswd(addr, userName, netConfig.Password)
 	} else if connConfig.UseUserKeyPassphrase {
-		var keyFilePath string = netConfig.KeyFile
+		var keyFilePath string = keyFile
 		if strings.Index(keyFilePath, ":") < 0 &&
 			strings.Index(keyFilePath, "/") != 0 &&
 			strings.Index(keyFilePath, "\\") != 0 {
 			keyFilePath = depConfig.WorkDir + string(os.PathSeparator) + keyFilePath
 		}
-		globalError = handler.ConnectWithKeyAndPassphrase(addr, netConfig.UserName, keyFilePath, netConfig.Passphrase)
+		globalError = handler.ConnectWithKeyAndPassphrase(addr, userName, keyFilePath, netConfig.Passphrase)
 	} else if connConfig.UseUserKey {
-		var keyFilePath string = netConfig.KeyFile
+		var keyFilePath string = keyFile
 		if strings.Index(keyFilePath, ":") < 0 &&
 			strings.Index(keyFilePath, "/") != 0 &&
 			strings.Index(keyFilePath, "\\") != 0 {
 			keyFilePath = depConfig.WorkDir + string(os.PathSeparator) + keyFilePath
 		}
-		globalError = handler.ConnectWithKey(addr, netConfig.UserName, keyFilePath)
+		globalError = handler.ConnectWithKey(addr, userName, keyFilePath)
 	} else if connConfig.UseTLSCertificates {
 		var keyFilePath string = netConfig.KeyFile
 		var certFilePath string = netConfig.Certificate
@@ -73,3 +114,57 @@ func ConnectHandlerViaConfig(connConfig module.ConnectionConfig, handler Connect
 	}
 	return handler.GetClient(), nil
 }
+
+// Converts the hosts file jump hosts chain, in connection order, jump hosts without credentials use the network configuration ones
+func hostJumpHosts(jumpHost *defaults.JumpHostValue, netConfig *module.NetProtocolType) []JumpHost {
+	var out []JumpHost = make([]JumpHost, 0)
+	for _, jump := range jumpHost.Chain() {
+		var port string = jump.Port
+		if port == "" {
+			port = sshconfig.DEFAULT_PORT
+		}
+		var value JumpHost = JumpHost{
+			Address:      fmt.Sprintf("%s:%s", jump.Address, port),
+			UserName:     jump.UserName,
+			Password:     jump.Password,
+			KeyFile:      jump.KeyFile,
+			Passphrase:   jump.Passphrase,
+			Fingerprints: jump.Fingerprints,
+		}
+		if value.UserName == "" {
+			value.UserName = netConfig.UserName
+		}
+		if value.Password == "" && value.KeyFile == "" {
+			value.Password = netConfig.Password
+			value.KeyFile = netConfig.KeyFile
+			value.Passphrase = netConfig.Passphrase
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// Name of the known hosts file in the Go Deploy system folder
+const KNOWN_HOSTS_FILE string = "known_hosts"
+
+// Creates the host keys verification policy of a host, by default strict with the known hosts file in the system folder
+func hostKeyPolicy(host defaults.HostValue, netConfig *module.NetProtocolType, depConfig *module.DeployConfig) (HostKeyPolicy, error) {
+	var checking module.HostKeyCheckingValue = module.HostKeyCheckingValue(strings.ToLower(string(netConfig.HostKeyChecking)))
+	if netConfig.Insecure {
+		checking = module.HOST_KEY_CHECKING_INSECURE
+	} else if checking == "" {
+		checking = module.HOST_KEY_CHECKING_STRICT
+	} else if checking != module.HOST_KEY_CHECKING_STRICT && checking != module.HOST_KEY_CHECKING_TOFU && checking != module.HOST_KEY_CHECKING_INSECURE {
+		return HostKeyPolicy{}, errors.New(fmt.Sprintf("Unknown host key checking: %s, available: %s, %s, %s", netConfig.HostKeyChecking,
+			module.HOST_KEY_CHECKING_STRICT, module.HOST_KEY_CHECKING_TOFU, module.HOST_KEY_CHECKING_INSECURE))
+	}
+	var knownHostsFile string = netConfig.KnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = depConfig.SystemDir + string(os.PathSeparator) + KNOWN_HOSTS_FILE
+	}
+	return HostKeyPolicy{
+		Checking:       checking,
+		KnownHostsFile: knownHostsFile,
+		Fingerprints:   host.Fingerprints,
+	}, nil
+}
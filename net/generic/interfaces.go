@@ -1,6 +1,7 @@
 package generic
 
 import (
+	"github.com/hellgate75/go-deploy/types/module"
 	"github.com/hellgate75/go-tcp-client/common"
 	"golang.org/x/crypto/ssh"
 	"io"
@@ -141,3 +142,39 @@ type ConnectionHandlerConfig struct {
 	UseSSHConfig                bool
 	UseCertificates             bool
 }
+
+// Jump host (bastion) address and credentials, the available ones are tried in order: key file, password
+type JumpHost struct {
+	Address      string
+	UserName     string
+	Password     string
+	KeyFile      string
+	Passphrase   string
+	Fingerprints []string
+}
+
+// Connection Handler able to reach the remote server through a chain of jump hosts
+type JumpConnectionHandler interface {
+	ConnectionHandler
+
+	// SetJumpHosts: Sets the jump hosts, in connection order, used by the following connections
+	SetJumpHosts(jumpHosts []JumpHost)
+}
+
+// Remote host keys verification policy
+type HostKeyPolicy struct {
+	// Verification mode: strict, trust-on-first-use or insecure
+	Checking module.HostKeyCheckingValue
+	// Known hosts file, used when no fingerprint is pinned
+	KnownHostsFile string
+	// Pinned remote host key fingerprints
+	Fingerprints []string
+}
+
+// Connection Handler verifying the remote host keys
+type HostKeyConnectionHandler interface {
+	ConnectionHandler
+
+	// SetHostKeyPolicy: Sets the host keys verification policy used by the following connections
+	SetHostKeyPolicy(policy HostKeyPolicy)
+}
@@ -0,0 +1,112 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/sshconfig"
+	"github.com/hellgate75/go-deploy/types/module"
+	"strings"
+)
+
+// Maximum depth of jump hosts chains resolved from the OpenSSH configuration
+const MAX_JUMP_HOSTS int = 8
+
+// Connection parameters of a host, after the OpenSSH configuration resolution
+type sshConnection struct {
+	address   string
+	userName  string
+	keyFile   string
+	jumpHosts []JumpHost
+}
+
+// Loads the OpenSSH configuration files, the configured one or the user and system default ones
+func loadSSHConfig(netConfig *module.NetProtocolType) (*sshconfig.Config, error) {
+	if netConfig.SSHConfigFile != "" {
+		return sshconfig.LoadCached(netConfig.SSHConfigFile)
+	}
+	return sshconfig.LoadCached(sshconfig.DefaultFiles()...)
+}
+
+// Resolves host name, port, user, identity file and jump hosts of a host from the OpenSSH configuration.
+// OpenSSH configuration values replace the network configuration user and key file. The OpenSSH configuration port is
+// preferred, unless the hosts file port overrides it with a value different from the default one
+func resolveSSHConnection(hostRef string, port string, netConfig *module.NetProtocolType) (*sshConnection, error) {
+	config, err := loadSSHConfig(netConfig)
+	if err != nil {
+		return nil, err
+	}
+	var hostConfig *sshconfig.HostConfig = config.Resolve(hostRef)
+	if hostConfig.ProxyCommand != "" && strings.ToLower(hostConfig.ProxyCommand) != "none" && Logger != nil {
+		Logger.Warnf("SSH config: ProxyCommand is not supported, ignored for host: %s", hostRef)
+	}
+	if port == "" || port == sshconfig.DEFAULT_PORT {
+		port = bestValue(hostConfig.Port, sshconfig.DEFAULT_PORT)
+	}
+	var connection *sshConnection = &sshConnection{
+		address:   fmt.Sprintf("%s:%s", hostConfig.HostName, port),
+		userName:  bestValue(hostConfig.User, netConfig.UserName),
+		keyFile:   bestValue(hostConfig.IdentityFile(), netConfig.KeyFile),
+		jumpHosts: make([]JumpHost, 0),
+	}
+	connection.jumpHosts, err = resolveJumpHosts(config, hostConfig.JumpHosts(), netConfig, 0)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("SSH config: host %s -> %s", hostRef, err.Error()))
+	}
+	return connection, nil
+}
+
+// Resolves the ProxyJump entries ([user@]host[:port]), the jump hosts of each entry are resolved too and they precede it
+func resolveJumpHosts(config *sshconfig.Config, jumps []string, netConfig *module.NetProtocolType, depth int) ([]JumpHost, error) {
+	var out []JumpHost = make([]JumpHost, 0)
+	if len(jumps) == 0 {
+		return out, nil
+	}
+	if depth >= MAX_JUMP_HOSTS {
+		return nil, errors.New(fmt.Sprintf("Too many nested ProxyJump, maximum is %v", MAX_JUMP_HOSTS))
+	}
+	for _, jump := range jumps {
+		var user string = ""
+		var port string = ""
+		var host string = jump
+		if index := strings.LastIndex(host, "@"); index >= 0 {
+			user = host[:index]
+			host = host[index+1:]
+		}
+		if index := strings.LastIndex(host, ":"); index >= 0 && strings.Index(host, "]") < index {
+			port = host[index+1:]
+			host = host[:index]
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		if host == "" {
+			return nil, errors.New(fmt.Sprintf("Invalid ProxyJump entry: %s", jump))
+		}
+		var hostConfig *sshconfig.HostConfig = config.Resolve(host)
+		chain, err := resolveJumpHosts(config, hostConfig.JumpHosts(), netConfig, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chain...)
+		port = bestValue(port, hostConfig.Port)
+		if port == "" {
+			port = sshconfig.DEFAULT_PORT
+		}
+		var jumpHost JumpHost = JumpHost{
+			Address:    fmt.Sprintf("%s:%s", hostConfig.HostName, port),
+			UserName:   bestValue(user, bestValue(hostConfig.User, netConfig.UserName)),
+			KeyFile:    bestValue(hostConfig.IdentityFile(), netConfig.KeyFile),
+			Passphrase: netConfig.Passphrase,
+		}
+		if hostConfig.IdentityFile() == "" {
+			jumpHost.Password = netConfig.Password
+		}
+		out = append(out, jumpHost)
+	}
+	return out, nil
+}
+
+func bestValue(value string, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
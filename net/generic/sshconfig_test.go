@@ -0,0 +1,36 @@
+package generic
+
+import (
+	"github.com/hellgate75/go-deploy/types/module"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSSHConnectionPort(t *testing.T) {
+	var file string = filepath.Join(t.TempDir(), "config")
+	var content string = "Host web1\n  HostName 10.0.0.1\n  Port 2222\n\nHost db1\n  HostName 10.0.0.2\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("Unable to write ssh config: %v", err)
+	}
+	var netConfig *module.NetProtocolType = &module.NetProtocolType{SSHConfigFile: file}
+	for _, test := range []struct {
+		host     string
+		port     string
+		expected string
+	}{
+		{"web1", "", "10.0.0.1:2222"},
+		{"web1", "22", "10.0.0.1:2222"},
+		{"web1", "2200", "10.0.0.1:2200"},
+		{"db1", "", "10.0.0.2:22"},
+		{"db1", "2200", "10.0.0.2:2200"},
+	} {
+		connection, err := resolveSSHConnection(test.host, test.port, netConfig)
+		if err != nil {
+			t.Fatalf("Unable to resolve %s: %v", test.host, err)
+		}
+		if connection.address != test.expected {
+			t.Errorf("Host %s with port \"%s\": expected address %s, got %s", test.host, test.port, test.expected, connection.address)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package local
+
+import (
+	"bytes"
+	"errors"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Local shell command and its script argument flag
+func shellCommand() (string, string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", "/C"
+	}
+	return "sh", "-c"
+}
+
+type localClient struct {
+}
+
+func (client *localClient) Close() error {
+	return nil
+}
+
+func (client *localClient) Clone() generic.NetworkClient {
+	return &localClient{}
+}
+
+func (client *localClient) Terminal(config *generic.TerminalConfig) generic.RemoteShell {
+	return &localShell{}
+}
+
+func (client *localClient) NewCmd(cmd string) generic.CommandsScript {
+	return (&commandsScript{}).NewCmd(cmd)
+}
+
+func (client *localClient) Script(script string) generic.CommandsScript {
+	return &commandsScript{
+		commands: []string{script},
+	}
+}
+
+func (client *localClient) ScriptFile(fname string) generic.CommandsScript {
+	data, err := ioutil.ReadFile(fname)
+	return &commandsScript{
+		commands: []string{string(data)},
+		err:      err,
+	}
+}
+
+func (client *localClient) Shell() generic.RemoteShell {
+	return &localShell{}
+}
+
+func (client *localClient) FileTranfer() generic.FileTransfer {
+	return &fileTransfer{}
+}
+
+type commandsScript struct {
+	commands []string
+	stdout   io.Writer
+	stderr   io.Writer
+	err      error
+}
+
+func (script *commandsScript) run(combined bool) ([]byte, error) {
+	if script.err != nil {
+		return nil, script.err
+	}
+	if len(script.commands) == 0 {
+		return nil, errors.New("No command to execute")
+	}
+	shell, flag := shellCommand()
+	var cmd *exec.Cmd = exec.Command(shell, flag, strings.Join(script.commands, "\n"))
+	var output bytes.Buffer
+	var stdout io.Writer = &output
+	if script.stdout != nil {
+		stdout = io.MultiWriter(&output, script.stdout)
+	}
+	cmd.Stdout = stdout
+	if combined {
+		if script.stderr != nil {
+			cmd.Stderr = io.MultiWriter(stdout, script.stderr)
+		} else {
+			cmd.Stderr = stdout
+		}
+	} else if script.stderr != nil {
+		cmd.Stderr = script.stderr
+	}
+	err := cmd.Run()
+	return output.Bytes(), err
+}
+
+func (script *commandsScript) ExecuteWithOutput() ([]byte, error) {
+	return script.run(false)
+}
+
+func (script *commandsScript) ExecuteWithFullOutput() ([]byte, error) {
+	return script.run(true)
+}
+
+func (script *commandsScript) SetStdio(stdout, stderr io.Writer) generic.CommandsScript {
+	script.stdout = stdout
+	script.stderr = stderr
+	return script
+}
+
+func (script *commandsScript) NewCmd(cmd string) generic.CommandsScript {
+	script.commands = append(script.commands, cmd)
+	return script
+}
+
+type localShell struct {
+	cmd    *exec.Cmd
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (shell *localShell) Close() error {
+	if shell.cmd == nil || shell.cmd.Process == nil {
+		return nil
+	}
+	err := shell.cmd.Process.Kill()
+	shell.cmd = nil
+	return err
+}
+
+func (shell *localShell) Start() error {
+	name, _ := shellCommand()
+	shell.cmd = exec.Command(name)
+	shell.cmd.Stdin = shell.stdin
+	shell.cmd.Stdout = shell.stdout
+	shell.cmd.Stderr = shell.stderr
+	if shell.cmd.Stdin == nil {
+		shell.cmd.Stdin = os.Stdin
+	}
+	return shell.cmd.Run()
+}
+
+func (shell *localShell) SetStdio(stdin io.Reader, stdout, stderr io.Writer) generic.RemoteShell {
+	shell.stdin = stdin
+	shell.stdout = stdout
+	shell.stderr = stderr
+	return shell
+}
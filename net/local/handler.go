@@ -0,0 +1,95 @@
+package local
+
+import (
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-tcp-client/common"
+	"github.com/hellgate75/go-tcp-common/log"
+	gossh "golang.org/x/crypto/ssh"
+	"sync"
+)
+
+var Logger log.Logger = nil
+
+// Connection Handler running commands and file transfers on the local machine, addresses and credentials are ignored
+type localConnectionHandler struct {
+	sync.Mutex
+	singleSession bool
+	client        *localClient
+}
+
+func (handler *localConnectionHandler) GetClient() generic.NetworkClient {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.client == nil {
+		return nil
+	}
+	return handler.client
+}
+
+func (handler *localConnectionHandler) IsConnected() bool {
+	handler.Lock()
+	defer handler.Unlock()
+	return handler.client != nil
+}
+
+func (handler *localConnectionHandler) Clone() generic.ConnectionHandler {
+	return &localConnectionHandler{
+		singleSession: handler.singleSession,
+	}
+}
+
+func (handler *localConnectionHandler) Close() error {
+	handler.Lock()
+	defer handler.Unlock()
+	handler.client = nil
+	return nil
+}
+
+func (handler *localConnectionHandler) UsePlugins(PluginLibraryExtension string, PluginLibrariesFolder string) {
+	if Logger != nil {
+		Logger.Debug("Local Connection Handler: built-in client, plugins are not used")
+	}
+}
+
+func (handler *localConnectionHandler) ConnectWithPasswd(addr string, user string, passwd string) error {
+	return handler.connect(addr)
+}
+
+func (handler *localConnectionHandler) ConnectWithKey(addr string, user string, keyfile string) error {
+	return handler.connect(addr)
+}
+
+func (handler *localConnectionHandler) ConnectWithKeyAndPassphrase(addr string, user, keyfile string, passphrase string) error {
+	return handler.connect(addr)
+}
+
+func (handler *localConnectionHandler) Connect(network, addr string, config *gossh.ClientConfig) error {
+	return handler.connect(addr)
+}
+
+func (handler *localConnectionHandler) ConnectWithCertificate(addr string, port string, certificate common.CertificateKeyPair, caCert string) error {
+	return handler.connect(addr)
+}
+
+func (handler *localConnectionHandler) connect(addr string) error {
+	if Logger != nil {
+		Logger.Debugf("Local Connection Handler: host %s runs on the local machine", addr)
+	}
+	handler.Lock()
+	defer handler.Unlock()
+	handler.client = &localClient{}
+	return nil
+}
+
+// Creates a new built-in Local Connection Handler and its configuration, all authentication modes are accepted and ignored
+func NewConnectionHandler(singleSession bool, insecure bool) (generic.ConnectionHandler, generic.ConnectionHandlerConfig) {
+	return &localConnectionHandler{
+		singleSession: singleSession,
+	}, generic.ConnectionHandlerConfig{
+		UseUserPassword:      true,
+		UseAuthKey:           true,
+		UseAuthKeyPassphrase: true,
+		UseSSHConfig:         false,
+		UseCertificates:      true,
+	}
+}
@@ -0,0 +1,122 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Default mode of folders created without explicit mode
+const DEFAULT_FOLDER_MODE os.FileMode = 0755
+
+// Local copies based File Transfer
+type fileTransfer struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (transfer *fileTransfer) log(format string, in ...interface{}) {
+	if transfer.stdout != nil {
+		fmt.Fprintf(transfer.stdout, format+"\n", in...)
+	}
+}
+
+func (transfer *fileTransfer) MkDir(path string) error {
+	return transfer.MkDirAs(path, DEFAULT_FOLDER_MODE)
+}
+
+func (transfer *fileTransfer) MkDirAs(path string, mode os.FileMode) error {
+	return mkDir(path, mode)
+}
+
+func (transfer *fileTransfer) TransferFileAs(path string, remotePath string, mode os.FileMode) error {
+	return transfer.copyFile(path, remotePath, mode)
+}
+
+func (transfer *fileTransfer) TransferFolderAs(path string, remotePath string, mode os.FileMode) error {
+	return transfer.copyFolder(path, remotePath, mode)
+}
+
+func (transfer *fileTransfer) TransferFile(path string, remotePath string) error {
+	return transfer.copyFile(path, remotePath, 0)
+}
+
+func (transfer *fileTransfer) TransferFolder(path string, remotePath string) error {
+	return transfer.copyFolder(path, remotePath, 0)
+}
+
+func (transfer *fileTransfer) SetStdio(stdout, stderr io.Writer) generic.FileTransfer {
+	transfer.stdout = stdout
+	transfer.stderr = stderr
+	return transfer
+}
+
+func mkDir(path string, mode os.FileMode) error {
+	if mode == 0 {
+		mode = DEFAULT_FOLDER_MODE
+	}
+	if err := os.MkdirAll(path, mode); err != nil {
+		return errors.New(fmt.Sprintf("Unable to create folder %s -> %s", path, err.Error()))
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return errors.New(fmt.Sprintf("Unable to change mode of folder %s -> %s", path, err.Error()))
+	}
+	return nil
+}
+
+// Copies a file to the target path, using the given mode or the source file mode if 0
+func (transfer *fileTransfer) copyFile(path string, targetPath string, mode os.FileMode) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return errors.New(fmt.Sprintf("Path %s is a folder", path))
+	}
+	if mode == 0 {
+		mode = info.Mode().Perm()
+	}
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create file %s -> %s", targetPath, err.Error()))
+	}
+	defer target.Close()
+	if _, err = io.Copy(target, source); err != nil {
+		return errors.New(fmt.Sprintf("Unable to copy file %s to %s -> %s", path, targetPath, err.Error()))
+	}
+	if err = target.Chmod(mode); err != nil {
+		return errors.New(fmt.Sprintf("Unable to change mode of file %s -> %s", targetPath, err.Error()))
+	}
+	transfer.log("Copied %s to %s", path, targetPath)
+	return nil
+}
+
+// Copies recursively a folder to the target path, using for files the given mode or the source files mode if 0
+func (transfer *fileTransfer) copyFolder(path string, targetPath string, mode os.FileMode) error {
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		var target string = filepath.Join(targetPath, relative)
+		if info.IsDir() {
+			var folderMode os.FileMode = DEFAULT_FOLDER_MODE
+			if mode == 0 {
+				folderMode = info.Mode().Perm()
+			}
+			return mkDir(target, folderMode)
+		}
+		return transfer.copyFile(file, target, mode)
+	})
+}
@@ -0,0 +1,188 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"github.com/hellgate75/go-deploy/net/generic"
+	gossh "golang.org/x/crypto/ssh"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+type sshClient struct {
+	client *gossh.Client
+	jumps  []*gossh.Client
+}
+
+func (client *sshClient) Close() error {
+	err := client.client.Close()
+	closeClients(client.jumps)
+	return err
+}
+
+func (client *sshClient) Clone() generic.NetworkClient {
+	return &sshClient{
+		client: client.client,
+		jumps:  client.jumps,
+	}
+}
+
+func (client *sshClient) Terminal(config *generic.TerminalConfig) generic.RemoteShell {
+	return &remoteShell{
+		client:   client.client,
+		terminal: config,
+	}
+}
+
+func (client *sshClient) NewCmd(cmd string) generic.CommandsScript {
+	return (&commandsScript{client: client.client}).NewCmd(cmd)
+}
+
+func (client *sshClient) Script(script string) generic.CommandsScript {
+	return &commandsScript{
+		client:   client.client,
+		commands: []string{script},
+	}
+}
+
+func (client *sshClient) ScriptFile(fname string) generic.CommandsScript {
+	data, err := ioutil.ReadFile(fname)
+	return &commandsScript{
+		client:   client.client,
+		commands: []string{string(data)},
+		err:      err,
+	}
+}
+
+func (client *sshClient) Shell() generic.RemoteShell {
+	return &remoteShell{
+		client: client.client,
+	}
+}
+
+func (client *sshClient) FileTranfer() generic.FileTransfer {
+	return &fileTransfer{
+		client: client.client,
+	}
+}
+
+func newSshClient(client *gossh.Client) *sshClient {
+	return &sshClient{
+		client: client,
+	}
+}
+
+// Creates a Network Client over a connected SSH client
+func NewClient(client *gossh.Client) generic.NetworkClient {
+	return newSshClient(client)
+}
+
+type commandsScript struct {
+	client   *gossh.Client
+	commands []string
+	stdout   io.Writer
+	stderr   io.Writer
+	err      error
+}
+
+func (script *commandsScript) run(combined bool) ([]byte, error) {
+	if script.err != nil {
+		return nil, script.err
+	}
+	if len(script.commands) == 0 {
+		return nil, errors.New("No command to execute")
+	}
+	session, err := script.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	var output bytes.Buffer
+	var stdout io.Writer = &output
+	if script.stdout != nil {
+		stdout = io.MultiWriter(&output, script.stdout)
+	}
+	session.Stdout = stdout
+	if combined {
+		if script.stderr != nil {
+			session.Stderr = io.MultiWriter(stdout, script.stderr)
+		} else {
+			session.Stderr = stdout
+		}
+	} else if script.stderr != nil {
+		session.Stderr = script.stderr
+	}
+	err = session.Run(strings.Join(script.commands, "\n"))
+	return output.Bytes(), err
+}
+
+func (script *commandsScript) ExecuteWithOutput() ([]byte, error) {
+	return script.run(false)
+}
+
+func (script *commandsScript) ExecuteWithFullOutput() ([]byte, error) {
+	return script.run(true)
+}
+
+func (script *commandsScript) SetStdio(stdout, stderr io.Writer) generic.CommandsScript {
+	script.stdout = stdout
+	script.stderr = stderr
+	return script
+}
+
+func (script *commandsScript) NewCmd(cmd string) generic.CommandsScript {
+	script.commands = append(script.commands, cmd)
+	return script
+}
+
+type remoteShell struct {
+	client   *gossh.Client
+	session  *gossh.Session
+	terminal *generic.TerminalConfig
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func (shell *remoteShell) Close() error {
+	if shell.session == nil {
+		return nil
+	}
+	err := shell.session.Close()
+	shell.session = nil
+	return err
+}
+
+func (shell *remoteShell) Start() error {
+	session, err := shell.client.NewSession()
+	if err != nil {
+		return err
+	}
+	shell.session = session
+	session.Stdin = shell.stdin
+	session.Stdout = shell.stdout
+	session.Stderr = shell.stderr
+	if shell.terminal != nil {
+		var modes gossh.TerminalModes = shell.terminal.Modes
+		if modes == nil {
+			modes = gossh.TerminalModes{}
+		}
+		err = session.RequestPty(shell.terminal.Term, shell.terminal.Height, shell.terminal.Weight, modes)
+		if err != nil {
+			return err
+		}
+	}
+	err = session.Shell()
+	if err != nil {
+		return err
+	}
+	return session.Wait()
+}
+
+func (shell *remoteShell) SetStdio(stdin io.Reader, stdout, stderr io.Writer) generic.RemoteShell {
+	shell.stdin = stdin
+	shell.stdout = stdout
+	shell.stderr = stderr
+	return shell
+}
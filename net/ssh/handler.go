@@ -0,0 +1,269 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-tcp-client/common"
+	"github.com/hellgate75/go-tcp-common/log"
+	gossh "golang.org/x/crypto/ssh"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var Logger log.Logger = nil
+
+// Timeout of the SSH connection establishment
+var ConnectTimeout time.Duration = 30 * time.Second
+
+// Known hosts file used to verify the remote host keys, when no host key policy is set and connections are not insecure
+var KnownHostsFile string = filepath.Join(userHomeDir(), ".ssh", "known_hosts")
+
+type sshConnectionHandler struct {
+	sync.Mutex
+	singleSession bool
+	insecure      bool
+	client        *sshClient
+	jumpHosts     []generic.JumpHost
+	hostKeyPolicy *generic.HostKeyPolicy
+}
+
+func (handler *sshConnectionHandler) GetClient() generic.NetworkClient {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.client == nil {
+		return nil
+	}
+	return handler.client
+}
+
+func (handler *sshConnectionHandler) IsConnected() bool {
+	handler.Lock()
+	defer handler.Unlock()
+	return handler.client != nil
+}
+
+func (handler *sshConnectionHandler) Clone() generic.ConnectionHandler {
+	handler.Lock()
+	defer handler.Unlock()
+	return &sshConnectionHandler{
+		singleSession: handler.singleSession,
+		insecure:      handler.insecure,
+		jumpHosts:     handler.jumpHosts,
+		hostKeyPolicy: handler.hostKeyPolicy,
+	}
+}
+
+func (handler *sshConnectionHandler) Close() error {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.client == nil {
+		return nil
+	}
+	err := handler.client.Close()
+	handler.client = nil
+	return err
+}
+
+func (handler *sshConnectionHandler) UsePlugins(PluginLibraryExtension string, PluginLibrariesFolder string) {
+	if Logger != nil {
+		Logger.Debug("SSH Connection Handler: built-in client, plugins are not used")
+	}
+}
+
+func (handler *sshConnectionHandler) ConnectWithPasswd(addr string, user string, passwd string) error {
+	return handler.connectWithAuth(addr, user, gossh.Password(passwd))
+}
+
+func (handler *sshConnectionHandler) ConnectWithKey(addr string, user string, keyfile string) error {
+	signer, err := readSigner(keyfile, "")
+	if err != nil {
+		return err
+	}
+	return handler.connectWithAuth(addr, user, gossh.PublicKeys(signer))
+}
+
+func (handler *sshConnectionHandler) ConnectWithKeyAndPassphrase(addr string, user, keyfile string, passphrase string) error {
+	signer, err := readSigner(keyfile, passphrase)
+	if err != nil {
+		return err
+	}
+	return handler.connectWithAuth(addr, user, gossh.PublicKeys(signer))
+}
+
+func (handler *sshConnectionHandler) Connect(network, addr string, config *gossh.ClientConfig) error {
+	jumps, err := handler.dialJumpHosts(network)
+	if err != nil {
+		return err
+	}
+	var client *gossh.Client
+	if len(jumps) == 0 {
+		client, err = gossh.Dial(network, addr, config)
+	} else {
+		client, err = dialVia(jumps[len(jumps)-1], network, addr, config)
+	}
+	if err != nil {
+		closeClients(jumps)
+		return errors.New(fmt.Sprintf("SSH connection to %s failed -> %s", addr, err.Error()))
+	}
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.client != nil {
+		handler.client.Close()
+	}
+	handler.client = newSshClient(client)
+	handler.client.jumps = jumps
+	return nil
+}
+
+func (handler *sshConnectionHandler) SetJumpHosts(jumpHosts []generic.JumpHost) {
+	handler.Lock()
+	defer handler.Unlock()
+	handler.jumpHosts = jumpHosts
+}
+
+// Connects in order the jump hosts, each one through the previous one
+func (handler *sshConnectionHandler) dialJumpHosts(network string) ([]*gossh.Client, error) {
+	handler.Lock()
+	var jumpHosts []generic.JumpHost = handler.jumpHosts
+	handler.Unlock()
+	var jumps []*gossh.Client = make([]*gossh.Client, 0)
+	for _, jumpHost := range jumpHosts {
+		config, err := handler.jumpHostConfig(jumpHost)
+		if err != nil {
+			closeClients(jumps)
+			return nil, err
+		}
+		var client *gossh.Client
+		if len(jumps) == 0 {
+			client, err = gossh.Dial(network, jumpHost.Address, config)
+		} else {
+			client, err = dialVia(jumps[len(jumps)-1], network, jumpHost.Address, config)
+		}
+		if err != nil {
+			closeClients(jumps)
+			return nil, errors.New(fmt.Sprintf("SSH connection to jump host %s failed -> %s", jumpHost.Address, err.Error()))
+		}
+		if Logger != nil {
+			Logger.Debugf("SSH Connection Handler: connected to jump host %s", jumpHost.Address)
+		}
+		jumps = append(jumps, client)
+	}
+	return jumps, nil
+}
+
+func (handler *sshConnectionHandler) jumpHostConfig(jumpHost generic.JumpHost) (*gossh.ClientConfig, error) {
+	var auth []gossh.AuthMethod = make([]gossh.AuthMethod, 0)
+	var keyErr error
+	if jumpHost.KeyFile != "" {
+		signer, err := readSigner(jumpHost.KeyFile, jumpHost.Passphrase)
+		if err == nil {
+			auth = append(auth, gossh.PublicKeys(signer))
+		}
+		keyErr = err
+	}
+	if jumpHost.Password != "" {
+		auth = append(auth, gossh.Password(jumpHost.Password))
+	}
+	if len(auth) == 0 {
+		if keyErr != nil {
+			return nil, errors.New(fmt.Sprintf("No credentials for jump host %s -> %s", jumpHost.Address, keyErr.Error()))
+		}
+		return nil, errors.New(fmt.Sprintf("No credentials for jump host %s", jumpHost.Address))
+	}
+	return &gossh.ClientConfig{
+		User:            jumpHost.UserName,
+		Auth:            auth,
+		HostKeyCallback: handler.hostKeyCallback(jumpHost.Fingerprints),
+		Timeout:         ConnectTimeout,
+	}, nil
+}
+
+// Opens an SSH connection tunnelled through a connected SSH client
+func dialVia(via *gossh.Client, network string, addr string, config *gossh.ClientConfig) (*gossh.Client, error) {
+	conn, err := via.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, chans, reqs, err := gossh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return gossh.NewClient(clientConn, chans, reqs), nil
+}
+
+// Closes clients in reverse order
+func closeClients(clients []*gossh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
+
+func (handler *sshConnectionHandler) ConnectWithCertificate(addr string, port string, certificate common.CertificateKeyPair, caCert string) error {
+	return errors.New("SSH Connection Handler: TLS certificates authentication is not supported")
+}
+
+func (handler *sshConnectionHandler) connectWithAuth(addr string, user string, auth gossh.AuthMethod) error {
+	var fingerprints []string
+	handler.Lock()
+	if handler.hostKeyPolicy != nil {
+		fingerprints = handler.hostKeyPolicy.Fingerprints
+	}
+	handler.Unlock()
+	return handler.Connect("tcp", addr, &gossh.ClientConfig{
+		User:            user,
+		Auth:            []gossh.AuthMethod{auth},
+		HostKeyCallback: handler.hostKeyCallback(fingerprints),
+		Timeout:         ConnectTimeout,
+	})
+}
+
+// Reads a private key file, if the given file is a public key (.pub) the matching private key file is read
+func readSigner(keyfile string, passphrase string) (gossh.Signer, error) {
+	if strings.HasSuffix(keyfile, ".pub") {
+		if _, err := os.Stat(strings.TrimSuffix(keyfile, ".pub")); err == nil {
+			keyfile = strings.TrimSuffix(keyfile, ".pub")
+		}
+	}
+	data, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read key file %s -> %s", keyfile, err.Error()))
+	}
+	var signer gossh.Signer
+	if passphrase != "" {
+		signer, err = gossh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		signer, err = gossh.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to parse key file %s -> %s", keyfile, err.Error()))
+	}
+	return signer, nil
+}
+
+func userHomeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return home
+}
+
+// Creates a new built-in SSH Connection Handler and its configuration, host keys are not verified in insecure mode
+func NewConnectionHandler(singleSession bool, insecure bool) (generic.ConnectionHandler, generic.ConnectionHandlerConfig) {
+	return &sshConnectionHandler{
+		singleSession: singleSession,
+		insecure:      insecure,
+	}, generic.ConnectionHandlerConfig{
+		UseUserPassword:      true,
+		UseAuthKey:           true,
+		UseAuthKeyPassphrase: true,
+		UseSSHConfig:         true,
+		UseCertificates:      false,
+	}
+}
@@ -0,0 +1,237 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/pkg/sftp"
+	gossh "golang.org/x/crypto/ssh"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// In-process SSH server accepting a single user, it answers commands with their text, serves SFTP on the local
+// file system and forwards direct-tcpip channels
+type testServer struct {
+	sync.Mutex
+	listener    net.Listener
+	config      *gossh.ServerConfig
+	fingerprint string
+	commands    []string
+	forwards    int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate host key: %v", err)
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Unable to create host key signer: %v", err)
+	}
+	var server *testServer = &testServer{
+		config: &gossh.ServerConfig{
+			PasswordCallback: func(conn gossh.ConnMetadata, password []byte) (*gossh.Permissions, error) {
+				if conn.User() == "deploy" && string(password) == "secret" {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("Invalid credentials for %s", conn.User())
+			},
+		},
+		fingerprint: gossh.FingerprintSHA256(signer.PublicKey()),
+		commands:    make([]string, 0),
+	}
+	server.config.AddHostKey(signer)
+	server.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	t.Cleanup(func() {
+		server.listener.Close()
+	})
+	go server.serve()
+	return server
+}
+
+func (server *testServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *testServer) handle(conn net.Conn) {
+	serverConn, channels, requests, err := gossh.NewServerConn(conn, server.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	go gossh.DiscardRequests(requests)
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			channel, channelRequests, err := newChannel.Accept()
+			if err == nil {
+				go server.session(channel, channelRequests)
+			}
+		case "direct-tcpip":
+			go server.forward(newChannel)
+		default:
+			newChannel.Reject(gossh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (server *testServer) session(channel gossh.Channel, requests <-chan *gossh.Request) {
+	defer channel.Close()
+	for request := range requests {
+		switch request.Type {
+		case "exec":
+			var payload struct{ Command string }
+			gossh.Unmarshal(request.Payload, &payload)
+			server.Lock()
+			server.commands = append(server.commands, payload.Command)
+			server.Unlock()
+			request.Reply(true, nil)
+			fmt.Fprintf(channel, "executed: %s\n", payload.Command)
+			channel.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{0}))
+			return
+		case "subsystem":
+			var payload struct{ Name string }
+			gossh.Unmarshal(request.Payload, &payload)
+			if payload.Name != "sftp" {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+			sftpServer, err := sftp.NewServer(channel)
+			if err == nil {
+				sftpServer.Serve()
+			}
+			return
+		default:
+			request.Reply(false, nil)
+		}
+	}
+}
+
+func (server *testServer) forward(newChannel gossh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	gossh.Unmarshal(newChannel.ExtraData(), &payload)
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, fmt.Sprintf("%v", payload.Port)))
+	if err != nil {
+		newChannel.Reject(gossh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	server.Lock()
+	server.forwards++
+	server.Unlock()
+	go gossh.DiscardRequests(requests)
+	go func() {
+		io.Copy(channel, target)
+		channel.Close()
+	}()
+	io.Copy(target, channel)
+	target.Close()
+}
+
+func (server *testServer) address() string {
+	return server.listener.Addr().String()
+}
+
+func TestHandlerRunsCommandAndTransfersFile(t *testing.T) {
+	var server *testServer = newTestServer(t)
+	handler, _ := NewConnectionHandler(false, false)
+	// Host keys are verified by the pinned fingerprint, the known hosts file is never read
+	handler.(generic.HostKeyConnectionHandler).SetHostKeyPolicy(generic.HostKeyPolicy{
+		Checking:     module.HOST_KEY_CHECKING_STRICT,
+		Fingerprints: []string{server.fingerprint},
+	})
+	if err := handler.ConnectWithPasswd(server.address(), "deploy", "secret"); err != nil {
+		t.Fatalf("Unable to connect: %v", err)
+	}
+	defer handler.Close()
+	var client generic.NetworkClient = handler.GetClient()
+	output, err := client.NewCmd("uname -a").ExecuteWithOutput()
+	if err != nil {
+		t.Fatalf("Unable to execute command: %v", err)
+	}
+	if string(output) != "executed: uname -a\n" {
+		t.Fatalf("Unexpected command output: %q", string(output))
+	}
+
+	var folder string = t.TempDir()
+	var localPath string = filepath.Join(folder, "local.txt")
+	if err := ioutil.WriteFile(localPath, []byte("deployed content"), 0640); err != nil {
+		t.Fatalf("Unable to write local file: %v", err)
+	}
+	var remotePath string = filepath.Join(folder, "remote", "app.txt")
+	if err := client.FileTranfer().MkDir(filepath.Dir(remotePath)); err != nil {
+		t.Fatalf("Unable to create remote folder: %v", err)
+	}
+	if err := client.FileTranfer().TransferFileAs(localPath, remotePath, 0600); err != nil {
+		t.Fatalf("Unable to transfer file: %v", err)
+	}
+	data, err := ioutil.ReadFile(remotePath)
+	if err != nil {
+		t.Fatalf("Unable to read transferred file: %v", err)
+	}
+	if string(data) != "deployed content" {
+		t.Fatalf("Unexpected transferred content: %q", string(data))
+	}
+	if info, err := os.Stat(remotePath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Unexpected transferred file mode: %v %v", info, err)
+	}
+}
+
+func TestHandlerCloneKeepsJumpHostsAndHostKeyPolicy(t *testing.T) {
+	var server *testServer = newTestServer(t)
+	handler, _ := NewConnectionHandler(false, false)
+	handler.(generic.HostKeyConnectionHandler).SetHostKeyPolicy(generic.HostKeyPolicy{
+		Checking:     module.HOST_KEY_CHECKING_STRICT,
+		Fingerprints: []string{server.fingerprint},
+	})
+	handler.(generic.JumpConnectionHandler).SetJumpHosts([]generic.JumpHost{
+		{
+			Address:      server.address(),
+			UserName:     "deploy",
+			Password:     "secret",
+			Fingerprints: []string{server.fingerprint},
+		},
+	})
+	var clone generic.ConnectionHandler = handler.Clone()
+	if err := clone.ConnectWithPasswd(server.address(), "deploy", "secret"); err != nil {
+		t.Fatalf("Unable to connect with the cloned handler: %v", err)
+	}
+	defer clone.Close()
+	if _, err := clone.GetClient().NewCmd("hostname").ExecuteWithOutput(); err != nil {
+		t.Fatalf("Unable to execute command: %v", err)
+	}
+	server.Lock()
+	defer server.Unlock()
+	if server.forwards != 1 {
+		t.Fatalf("Expected a connection through the jump host, got %v forwarded connections", server.forwards)
+	}
+}
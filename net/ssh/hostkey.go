@@ -0,0 +1,126 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/types/module"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Serializes known hosts files reads and writes among parallel connections
+var knownHostsMutex sync.Mutex
+
+func (handler *sshConnectionHandler) SetHostKeyPolicy(policy generic.HostKeyPolicy) {
+	handler.Lock()
+	defer handler.Unlock()
+	handler.hostKeyPolicy = &policy
+}
+
+// Creates the host key verification callback, pinned fingerprints are preferred to the known hosts file.
+// Without a host key policy, host keys are verified against the default known hosts file
+func (handler *sshConnectionHandler) hostKeyCallback(fingerprints []string) gossh.HostKeyCallback {
+	if handler.insecure {
+		return gossh.InsecureIgnoreHostKey()
+	}
+	handler.Lock()
+	var policy generic.HostKeyPolicy = generic.HostKeyPolicy{
+		Checking:       module.HOST_KEY_CHECKING_STRICT,
+		KnownHostsFile: KnownHostsFile,
+	}
+	if handler.hostKeyPolicy != nil {
+		policy = *handler.hostKeyPolicy
+	}
+	handler.Unlock()
+	if policy.Checking == module.HOST_KEY_CHECKING_INSECURE {
+		return gossh.InsecureIgnoreHostKey()
+	}
+	if len(fingerprints) > 0 {
+		return pinnedCallback(fingerprints)
+	}
+	return knownHostsCallback(policy.KnownHostsFile, policy.Checking == module.HOST_KEY_CHECKING_TOFU)
+}
+
+// Accepts only host keys matching one of the given SHA256 or MD5 fingerprints
+func pinnedCallback(fingerprints []string) gossh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+		var sha256 string = gossh.FingerprintSHA256(key)
+		var md5 string = gossh.FingerprintLegacyMD5(key)
+		for _, fingerprint := range fingerprints {
+			fingerprint = strings.TrimSpace(fingerprint)
+			if fingerprint == sha256 || "SHA256:"+fingerprint == sha256 ||
+				strings.ToLower(strings.TrimPrefix(fingerprint, "MD5:")) == md5 {
+				return nil
+			}
+		}
+		return errors.New(fmt.Sprintf("Host key verification failed for %s: %s key fingerprint %s doesn't match the pinned fingerprints", hostname, key.Type(), sha256))
+	}
+}
+
+// Verifies host keys against the known hosts file, in trust on first use mode unknown hosts keys are recorded in the file.
+// Changed and revoked host keys are always refused
+func knownHostsCallback(file string, trustOnFirstUse bool) gossh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+		knownHostsMutex.Lock()
+		defer knownHostsMutex.Unlock()
+		var err error
+		if _, statErr := os.Stat(file); statErr == nil {
+			var callback gossh.HostKeyCallback
+			callback, err = knownhosts.New(file)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Host key verification failed for %s: unable to read known hosts file %s -> %s", hostname, file, err.Error()))
+			}
+			err = callback(hostname, remote, key)
+			if err == nil {
+				return nil
+			}
+		}
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+			var want knownhosts.KnownKey = keyErr.Want[0]
+			return errors.New(fmt.Sprintf("Host key verification failed for %s: %s key fingerprint %s doesn't match the known key at %s:%v, the remote host key changed or the connection is intercepted",
+				hostname, key.Type(), gossh.FingerprintSHA256(key), want.Filename, want.Line))
+		}
+		var revokedErr *knownhosts.RevokedError
+		if errors.As(err, &revokedErr) {
+			return errors.New(fmt.Sprintf("Host key verification failed for %s: %s key fingerprint %s is revoked", hostname, key.Type(), gossh.FingerprintSHA256(key)))
+		}
+		if err != nil && keyErr == nil {
+			return errors.New(fmt.Sprintf("Host key verification failed for %s -> %s", hostname, err.Error()))
+		}
+		if !trustOnFirstUse {
+			return errors.New(fmt.Sprintf("Host key verification failed for %s: %s key fingerprint %s is unknown, not present in known hosts file %s",
+				hostname, key.Type(), gossh.FingerprintSHA256(key), file))
+		}
+		if err := appendKnownHost(file, hostname, remote, key); err != nil {
+			return errors.New(fmt.Sprintf("Host key verification failed for %s: unable to record the host key in %s -> %s", hostname, file, err.Error()))
+		}
+		if Logger != nil {
+			Logger.Warnf("Trust on first use: %s key fingerprint %s of %s recorded in %s", key.Type(), gossh.FingerprintSHA256(key), hostname, file)
+		}
+		return nil
+	}
+}
+
+func appendKnownHost(file string, hostname string, remote net.Addr, key gossh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	var addresses []string = []string{knownhosts.Normalize(hostname)}
+	if remote != nil && knownhosts.Normalize(remote.String()) != addresses[0] {
+		addresses = append(addresses, knownhosts.Normalize(remote.String()))
+	}
+	_, err = fmt.Fprintln(out, knownhosts.Line(addresses, key))
+	return err
+}
@@ -0,0 +1,144 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/pkg/sftp"
+	gossh "golang.org/x/crypto/ssh"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Default mode of remote folders created without explicit mode
+const DEFAULT_FOLDER_MODE os.FileMode = 0755
+
+// SFTP based File Transfer
+type fileTransfer struct {
+	client *gossh.Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (transfer *fileTransfer) withSftp(action func(client *sftp.Client) error) error {
+	client, err := sftp.NewClient(transfer.client)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to open SFTP session -> %s", err.Error()))
+	}
+	defer client.Close()
+	return action(client)
+}
+
+func (transfer *fileTransfer) log(format string, in ...interface{}) {
+	if transfer.stdout != nil {
+		fmt.Fprintf(transfer.stdout, format+"\n", in...)
+	}
+}
+
+func (transfer *fileTransfer) MkDir(path string) error {
+	return transfer.MkDirAs(path, DEFAULT_FOLDER_MODE)
+}
+
+func (transfer *fileTransfer) MkDirAs(path string, mode os.FileMode) error {
+	return transfer.withSftp(func(client *sftp.Client) error {
+		return mkDir(client, path, mode)
+	})
+}
+
+func (transfer *fileTransfer) TransferFileAs(path string, remotePath string, mode os.FileMode) error {
+	return transfer.withSftp(func(client *sftp.Client) error {
+		return transfer.copyFile(client, path, remotePath, mode)
+	})
+}
+
+func (transfer *fileTransfer) TransferFolderAs(path string, remotePath string, mode os.FileMode) error {
+	return transfer.withSftp(func(client *sftp.Client) error {
+		return transfer.copyFolder(client, path, remotePath, mode)
+	})
+}
+
+func (transfer *fileTransfer) TransferFile(path string, remotePath string) error {
+	return transfer.withSftp(func(client *sftp.Client) error {
+		return transfer.copyFile(client, path, remotePath, 0)
+	})
+}
+
+func (transfer *fileTransfer) TransferFolder(path string, remotePath string) error {
+	return transfer.withSftp(func(client *sftp.Client) error {
+		return transfer.copyFolder(client, path, remotePath, 0)
+	})
+}
+
+func (transfer *fileTransfer) SetStdio(stdout, stderr io.Writer) generic.FileTransfer {
+	transfer.stdout = stdout
+	transfer.stderr = stderr
+	return transfer
+}
+
+func mkDir(client *sftp.Client, remotePath string, mode os.FileMode) error {
+	if err := client.MkdirAll(remotePath); err != nil {
+		return errors.New(fmt.Sprintf("Unable to create remote folder %s -> %s", remotePath, err.Error()))
+	}
+	if mode != 0 {
+		if err := client.Chmod(remotePath, mode); err != nil {
+			return errors.New(fmt.Sprintf("Unable to change mode of remote folder %s -> %s", remotePath, err.Error()))
+		}
+	}
+	return nil
+}
+
+// Copies a local file to the remote path, using the given mode or the local file mode if 0
+func (transfer *fileTransfer) copyFile(client *sftp.Client, localPath string, remotePath string, mode os.FileMode) error {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+	info, err := local.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return errors.New(fmt.Sprintf("Local path %s is a folder", localPath))
+	}
+	if mode == 0 {
+		mode = info.Mode().Perm()
+	}
+	remote, err := client.Create(remotePath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create remote file %s -> %s", remotePath, err.Error()))
+	}
+	defer remote.Close()
+	if _, err = io.Copy(remote, local); err != nil {
+		return errors.New(fmt.Sprintf("Unable to transfer file %s to %s -> %s", localPath, remotePath, err.Error()))
+	}
+	if err = remote.Chmod(mode); err != nil {
+		return errors.New(fmt.Sprintf("Unable to change mode of remote file %s -> %s", remotePath, err.Error()))
+	}
+	transfer.log("Transferred %s to %s", localPath, remotePath)
+	return nil
+}
+
+// Copies recursively a local folder to the remote path, using for files the given mode or the local files mode if 0
+func (transfer *fileTransfer) copyFolder(client *sftp.Client, localPath string, remotePath string, mode os.FileMode) error {
+	return filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		var target string = path.Join(remotePath, filepath.ToSlash(relative))
+		if info.IsDir() {
+			var folderMode os.FileMode = DEFAULT_FOLDER_MODE
+			if mode == 0 {
+				folderMode = info.Mode().Perm()
+			}
+			return mkDir(client, target, folderMode)
+		}
+		return transfer.copyFile(client, file, target, mode)
+	})
+}
@@ -0,0 +1,321 @@
+package sshconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-tcp-common/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var Logger log.Logger = nil
+
+// OpenSSH default port
+const DEFAULT_PORT string = "22"
+
+// Host parameters resolved from the OpenSSH configuration files
+type HostConfig struct {
+	HostName      string
+	User          string
+	Port          string
+	IdentityFiles []string
+	ProxyJump     string
+	ProxyCommand  string
+}
+
+// Retrieves the first identity file or an empty string
+func (hc *HostConfig) IdentityFile() string {
+	if len(hc.IdentityFiles) == 0 {
+		return ""
+	}
+	return hc.IdentityFiles[0]
+}
+
+// Retrieves the jump hosts, in connection order, listed in the ProxyJump parameter
+func (hc *HostConfig) JumpHosts() []string {
+	var out []string = make([]string, 0)
+	if hc.ProxyJump == "" || strings.ToLower(hc.ProxyJump) == "none" {
+		return out
+	}
+	for _, jump := range strings.Split(hc.ProxyJump, ",") {
+		jump = strings.TrimSpace(jump)
+		if jump != "" {
+			out = append(out, jump)
+		}
+	}
+	return out
+}
+
+func (hc *HostConfig) String() string {
+	return fmt.Sprintf("HostConfig{HostName: \"%s\", User: \"%s\", Port: \"%s\", IdentityFiles: %v, ProxyJump: \"%s\"}",
+		hc.HostName, hc.User, hc.Port, hc.IdentityFiles, hc.ProxyJump)
+}
+
+// Parameters block, applied when the host matches all the Host patterns lists: the block ones and the including blocks ones
+type hostBlock struct {
+	conditions [][]string
+	params     [][2]string
+}
+
+func (block hostBlock) matches(alias string) bool {
+	for _, patterns := range block.conditions {
+		if !matchPatterns(patterns, alias) {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenSSH configuration, as a sequence of Host blocks
+type Config struct {
+	blocks []hostBlock
+}
+
+// Resolves the parameters for the given host alias, as OpenSSH does the first obtained value of each parameter is used
+func (config *Config) Resolve(alias string) *HostConfig {
+	var out *HostConfig = &HostConfig{
+		IdentityFiles: make([]string, 0),
+	}
+	for _, block := range config.blocks {
+		if !block.matches(alias) {
+			continue
+		}
+		for _, param := range block.params {
+			var value string = param[1]
+			switch param[0] {
+			case "hostname":
+				if out.HostName == "" {
+					out.HostName = value
+				}
+			case "user":
+				if out.User == "" {
+					out.User = value
+				}
+			case "port":
+				if out.Port == "" {
+					out.Port = value
+				}
+			case "identityfile":
+				out.IdentityFiles = append(out.IdentityFiles, value)
+			case "proxyjump":
+				if out.ProxyJump == "" {
+					out.ProxyJump = value
+				}
+			case "proxycommand":
+				if out.ProxyCommand == "" {
+					out.ProxyCommand = value
+				}
+			}
+		}
+	}
+	if out.HostName == "" {
+		out.HostName = alias
+	} else {
+		out.HostName = strings.ReplaceAll(out.HostName, "%h", alias)
+	}
+	for i, file := range out.IdentityFiles {
+		out.IdentityFiles[i] = expandPath(file, alias, out.User)
+	}
+	return out
+}
+
+// Parses the given OpenSSH configuration files, in order, missing files are ignored
+func Load(paths ...string) (*Config, error) {
+	var config *Config = &Config{
+		blocks: make([]hostBlock, 0),
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := config.parseFile(path, [][]string{}, 0); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+var (
+	cache      map[string]*Config = make(map[string]*Config)
+	cacheMutex sync.Mutex
+)
+
+// Parses once the given OpenSSH configuration files and retrieves the parsed configuration on next calls
+func LoadCached(paths ...string) (*Config, error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	var key string = strings.Join(paths, string(os.PathListSeparator))
+	if config, ok := cache[key]; ok {
+		return config, nil
+	}
+	config, err := Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+	cache[key] = config
+	return config, nil
+}
+
+// Retrieves the default OpenSSH configuration files: user and system ones
+func DefaultFiles() []string {
+	return []string{
+		filepath.Join(userHomeDir(), ".ssh", "config"),
+		"/etc/ssh/ssh_config",
+	}
+}
+
+const maxIncludeDepth int = 16
+
+// Parses a configuration file, its blocks apply only when the including blocks conditions match
+func (config *Config) parseFile(path string, conditions [][]string, depth int) error {
+	if depth > maxIncludeDepth {
+		return errors.New(fmt.Sprintf("Too many nested Include in ssh config file: %s", path))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to open ssh config file: %s -> %s", path, err.Error()))
+	}
+	defer file.Close()
+	config.blocks = append(config.blocks, hostBlock{conditions: conditions})
+	var current int = len(config.blocks) - 1
+	var scanner *bufio.Scanner = bufio.NewScanner(file)
+	var lineNumber int = 0
+	for scanner.Scan() {
+		lineNumber++
+		key, values := splitLine(scanner.Text())
+		if key == "" {
+			continue
+		}
+		if len(values) == 0 {
+			return errors.New(fmt.Sprintf("Missing value for %s in ssh config file: %s at line %v", key, path, lineNumber))
+		}
+		switch key {
+		case "host":
+			config.blocks = append(config.blocks, hostBlock{conditions: appendCondition(conditions, values)})
+			current = len(config.blocks) - 1
+		case "match":
+			// Match criteria are not supported, the block parameters are never applied
+			if Logger != nil {
+				Logger.Warnf("SSH config: Match is not supported, block ignored in file: %s at line %v", path, lineNumber)
+			}
+			config.blocks = append(config.blocks, hostBlock{conditions: appendCondition(conditions, []string{})})
+			current = len(config.blocks) - 1
+		case "include":
+			var blockConditions [][]string = config.blocks[current].conditions
+			for _, include := range values {
+				matches, _ := filepath.Glob(includePath(include, path))
+				for _, match := range matches {
+					if err := config.parseFile(match, blockConditions, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			// Included files parameters must not leak in the including block
+			config.blocks = append(config.blocks, hostBlock{conditions: blockConditions})
+			current = len(config.blocks) - 1
+		default:
+			config.blocks[current].params = append(config.blocks[current].params, [2]string{key, strings.Join(values, " ")})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.New(fmt.Sprintf("Unable to read ssh config file: %s -> %s", path, err.Error()))
+	}
+	return nil
+}
+
+// Splits a configuration line in a lower case keyword and its arguments, supporting "key value", "key=value" and quoted arguments
+func splitLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+	var index int = strings.IndexAny(line, " \t=")
+	if index < 0 {
+		return strings.ToLower(line), []string{}
+	}
+	var key string = strings.ToLower(line[:index])
+	var rest string = strings.TrimLeft(line[index:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	var values []string = make([]string, 0)
+	var value strings.Builder
+	var quoted bool = false
+	var hasValue bool = false
+	for _, c := range rest {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			hasValue = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if hasValue {
+				values = append(values, value.String())
+				value.Reset()
+				hasValue = false
+			}
+		default:
+			value.WriteRune(c)
+			hasValue = true
+		}
+	}
+	if hasValue {
+		values = append(values, value.String())
+	}
+	return key, values
+}
+
+// Verifies if the host alias matches the Host patterns, a negated pattern (!pattern) match excludes the host
+func matchPatterns(patterns []string, alias string) bool {
+	var matched bool = false
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if ok, _ := filepath.Match(strings.ToLower(pattern[1:]), strings.ToLower(alias)); ok {
+				return false
+			}
+		} else if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(alias)); ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+func appendCondition(conditions [][]string, patterns []string) [][]string {
+	var out [][]string = make([][]string, 0, len(conditions)+1)
+	out = append(out, conditions...)
+	return append(out, patterns)
+}
+
+func includePath(include string, parent string) string {
+	include = expandPath(include, "", "")
+	if filepath.IsAbs(include) {
+		return include
+	}
+	if strings.HasPrefix(parent, "/etc/ssh") {
+		return filepath.Join("/etc/ssh", include)
+	}
+	return filepath.Join(userHomeDir(), ".ssh", include)
+}
+
+// Expands the home folder (~ and %d), host (%h) and remote user (%r) tokens
+func expandPath(path string, host string, user string) string {
+	var home string = userHomeDir()
+	if path == "~" {
+		path = home
+	} else if strings.HasPrefix(path, "~/") {
+		path = filepath.Join(home, path[2:])
+	}
+	path = strings.ReplaceAll(path, "%d", home)
+	path = strings.ReplaceAll(path, "%h", host)
+	path = strings.ReplaceAll(path, "%r", user)
+	path = strings.ReplaceAll(path, "%%", "%")
+	return path
+}
+
+func userHomeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return home
+}
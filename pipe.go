@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/cmd"
+	"github.com/hellgate75/go-deploy/types/generic"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/utils"
+	"github.com/hellgate75/go-tcp-common/log"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	pipeStdinTarget string = "-"
+	pipeStatusOk    string = "ok"
+	pipeStatusKo    string = "ko"
+)
+
+// Result record written for each document read in PIPE_SOURCE mode
+type pipeResultRecord struct {
+	Document int       `json:"document"`
+	Feed     string    `json:"feed"`
+	Status   string    `json:"status"`
+	Errors   []string  `json:"errors,omitempty"`
+	Start    time.Time `json:"start"`
+	Elapsed  string    `json:"elapsed"`
+}
+
+// Writer that receives the per document JSON records, one per line
+var pipeResultsWriter io.Writer = os.Stdout
+
+// Writer that receives the log messages in PIPE_SOURCE mode, keeping the results writer for the JSON records only
+var pipeLogsWriter io.Writer = os.Stderr
+
+// Log levels, from the most verbose
+var pipeLogLevels []log.LogLevel = []log.LogLevel{log.TRACE, log.DEBUG, log.INFO, log.WARN, log.ERROR, log.FATAL}
+
+// Logger that writes the messages to the pipe logs writer, honouring the verbosity of the wrapped logger
+type pipeLogger struct {
+	log.Logger
+}
+
+func (logger *pipeLogger) write(level log.LogLevel, message string) {
+	var verbosity int = 0
+	var current int = 0
+	for index, item := range pipeLogLevels {
+		if item == logger.GetVerbosity() {
+			verbosity = index
+		}
+		if item == level {
+			current = index
+		}
+	}
+	if current >= verbosity {
+		fmt.Fprintf(pipeLogsWriter, "%s [%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), level, strings.TrimRight(message, "\n"))
+	}
+}
+
+func (logger *pipeLogger) Trace(in ...interface{}) {
+	logger.write(log.TRACE, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Tracef(format string, in ...interface{}) {
+	logger.write(log.TRACE, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Debug(in ...interface{}) {
+	logger.write(log.DEBUG, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Debugf(format string, in ...interface{}) {
+	logger.write(log.DEBUG, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Info(in ...interface{}) {
+	logger.write(log.INFO, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Infof(format string, in ...interface{}) {
+	logger.write(log.INFO, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Warn(in ...interface{}) {
+	logger.write(log.WARN, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Warnf(format string, in ...interface{}) {
+	logger.write(log.WARN, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Error(in ...interface{}) {
+	logger.write(log.ERROR, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Errorf(format string, in ...interface{}) {
+	logger.write(log.ERROR, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Fatal(in ...interface{}) {
+	logger.write(log.FATAL, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Fatalf(format string, in ...interface{}) {
+	logger.write(log.FATAL, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Success(in ...interface{}) {
+	logger.write(log.INFO, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Successf(format string, in ...interface{}) {
+	logger.write(log.INFO, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Failure(in ...interface{}) {
+	logger.write(log.ERROR, fmt.Sprint(in...))
+}
+
+func (logger *pipeLogger) Failuref(format string, in ...interface{}) {
+	logger.write(log.ERROR, fmt.Sprintf(format, in...))
+}
+
+func (logger *pipeLogger) Println(in ...interface{}) {
+	fmt.Fprintln(pipeLogsWriter, in...)
+}
+
+func (logger *pipeLogger) Printf(format string, in ...interface{}) {
+	fmt.Fprintf(pipeLogsWriter, format, in...)
+}
+
+// Creates a logger writing to the pipe logs writer, with the verbosity of the given logger
+func newPipeLogger(logger log.Logger) log.Logger {
+	return &pipeLogger{
+		Logger: logger,
+	}
+}
+
+// Reads feeds from the standard input (target "-") or from a named pipe, running each document as a separate feed
+func runPipeFeeds(boostrap cmd.Bootstrap, dc *module.DeployConfig, target string) error {
+	var reader io.Reader = os.Stdin
+	var location string = "stdin"
+	if target != pipeStdinTarget {
+		location = utils.ResolvePath(target, dc.WorkDir)
+		Logger.Warnf("Opening feeds pipe: %s", location)
+		file, err := os.Open(location)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		Logger.Warn("Reading feeds from standard input ...")
+	}
+	var encoder *json.Encoder = json.NewEncoder(pipeResultsWriter)
+	var failures int = 0
+	err := generic.ReadFeedStream(reader, func(index int, feed generic.IFeed, errR error) bool {
+		var record pipeResultRecord = pipeResultRecord{
+			Document: index,
+			Status:   pipeStatusOk,
+			Start:    time.Now(),
+		}
+		if f, ok := feed.(*generic.Feed); ok {
+			record.Feed = f.Name
+		}
+		if errR == nil {
+			Logger.Warnf("Running document %v from %s", index, location)
+			errR = executeFeed(boostrap, feed, fmt.Sprintf("%s#%v", location, index), cmd.RunOverrides{ExtraVars: cmd.GetExtraVars()})
+		}
+		if errR != nil {
+			Logger.Errorf("Document %v failed -> Details: \n%s", index, errR.Error())
+			record.Status = pipeStatusKo
+			record.Errors = []string{errR.Error()}
+			failures++
+		}
+		record.Elapsed = time.Now().Sub(record.Start).String()
+		if errW := encoder.Encode(record); errW != nil {
+			Logger.Errorf("Unable to write result for document %v, cause: %s", index, errW.Error())
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if failures > 0 {
+		return errors.New(fmt.Sprintf("%v document(s) failed reading from %s", failures, location))
+	}
+	return nil
+}
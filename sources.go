@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/generic"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/utils"
+	"github.com/hellgate75/go-tcp-common/io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	feedsCacheFolder string = "feeds-cache"
+)
+
+// Creates the HTTP Feed Source, using deploy type TLS settings, and resolves the target against the deploy type base url
+func newHttpFeedSource(dc *module.DeployConfig, dt *module.DeployType, target string) (generic.FeedSource, string, error) {
+	client, err := newSourceHttpClient(dc, dt)
+	if err != nil {
+		return nil, "", err
+	}
+	var cacheDir string = ""
+	if dc.SystemDir != "" {
+		cacheDir = dc.SystemDir + io.GetPathSeparator() + feedsCacheFolder
+	}
+	location, err := resolveSourceUrl(dt, target)
+	if err != nil {
+		return nil, "", err
+	}
+	return generic.NewHttpFeedSource(client, cacheDir), location, nil
+}
+
+// Calls the deployment descriptor REST service, using deploy type method, post body and TLS settings
+func fetchRestFeedEnvelope(dc *module.DeployConfig, dt *module.DeployType, target string) (*generic.RestFeedEnvelope, string, error) {
+	client, err := newSourceHttpClient(dc, dt)
+	if err != nil {
+		return nil, "", err
+	}
+	location, err := resolveSourceUrl(dt, target)
+	if err != nil {
+		return nil, "", err
+	}
+	Logger.Infof("Calling deployment descriptor service: %s %s", dt.Method, location)
+	envelope, err := generic.FetchRestFeedEnvelope(client, dt.Method, location, dt.PostBody)
+	if err != nil {
+		return nil, "", err
+	}
+	return envelope, location, nil
+}
+
+func newSourceHttpClient(dc *module.DeployConfig, dt *module.DeployType) (*http.Client, error) {
+	return utils.NewHttpClient(utils.ResolvePath(dt.CaCert, dc.WorkDir), utils.ResolvePath(dt.Certificate, dc.WorkDir),
+		utils.ResolvePath(dt.KeyFile, dc.WorkDir), dt.Insecure, time.Duration(dc.ReadTimeout)*time.Second)
+}
+
+func resolveSourceUrl(dt *module.DeployType, target string) (string, error) {
+	location, err := generic.NewHttpFeedSource(nil, "").Resolve(dt.BaseUrl, target)
+	if err != nil {
+		return "", err
+	}
+	if u, errU := url.Parse(location); errU != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", errors.New(fmt.Sprintf("Invalid feed url: %s, please provide an absolute url or a deploy type baseUrl", location))
+	}
+	return location, nil
+}
+
+// Verifies that a triggered feed is a url relative to the deploy type base url, that doesn't leave the base url host
+// and folder
+func checkTriggeredFeedUrl(dt *module.DeployType, feed string) error {
+	var invalid error = errors.New(fmt.Sprintf("Feed must be a url relative to the deploy type baseUrl: %s", feed))
+	if dt.BaseUrl == "" {
+		return errors.New(fmt.Sprintf("Feed %s: triggered feeds require a deploy type baseUrl", feed))
+	}
+	ref, err := url.Parse(feed)
+	if err != nil || ref.IsAbs() || ref.Host != "" {
+		return invalid
+	}
+	location, err := resolveSourceUrl(dt, feed)
+	if err != nil {
+		return err
+	}
+	base, errB := url.Parse(dt.BaseUrl)
+	target, errT := url.Parse(location)
+	if errB != nil || errT != nil || target.Scheme != base.Scheme || target.Host != base.Host {
+		return invalid
+	}
+	var folder string = base.Path[:strings.LastIndex(base.Path, "/")+1]
+	if !strings.HasPrefix(target.Path, folder) {
+		return invalid
+	}
+	for _, segment := range strings.Split(target.Path, "/") {
+		if segment == ".." {
+			return invalid
+		}
+	}
+	return nil
+}
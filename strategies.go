@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gookit/color"
+	"github.com/hellgate75/go-deploy/cmd"
+	"github.com/hellgate75/go-deploy/daemon"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/generic"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/schedule"
+	"github.com/hellgate75/go-deploy/utils"
+	"github.com/hellgate75/go-deploy/worker"
+	"github.com/hellgate75/go-tcp-common/io"
+	"github.com/hellgate75/go-tcp-common/log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Clock used by the PERIODIC_DEPLOYMENT strategy schedules and by the CONTINUOUS_DEPLOYMENT strategy watcher
+var deploymentClock schedule.Clock = schedule.NewSystemClock()
+
+// Interval between two checks of the watched files in CONTINUOUS_DEPLOYMENT strategy
+var continuousPollInterval time.Duration = 1 * time.Second
+
+// Quiet time required after the last change before running the deploy in CONTINUOUS_DEPLOYMENT strategy
+var continuousDebounce time.Duration = 2 * time.Second
+
+// Creates a channel closed when the process receives an interrupt or a termination signal
+func stopOnSignal() <-chan struct{} {
+	var stop chan struct{} = make(chan struct{})
+	var signals chan os.Signal = make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		Logger.Warnf("Received signal %v, stopping ...", sig)
+		close(stop)
+	}()
+	return stop
+}
+
+// Runs the deploy at each activation of the deploy type scheduled expression, reloading configuration and feeds on each
+// run, until the process is stopped
+func runPeriodicDeployment(config *module.DeployConfig, target string, scheduled string) error {
+	sched, err := schedule.Parse(scheduled)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid deploy type scheduled expression: \"%s\" -> %s", scheduled, err.Error()))
+	}
+	Logger.Warnf("Periodic deployment scheduled with: %s", scheduled)
+	var runner *schedule.Runner = schedule.NewRunner(sched, deploymentClock)
+	runner.Run(func() {
+		var start time.Time = deploymentClock.Now()
+		Logger.Warnf("Starting scheduled deploy at: %s", start.Format("2006-01-02 15:04:05 MST"))
+		var boostrap cmd.Bootstrap = cmd.NewBootStrap()
+		dc, dt, errC := loadConfiguration(boostrap, config)
+		if errC != nil {
+			Logger.Errorf("Error: %s", errC.Error())
+			return
+		}
+		errD := runDeployment(boostrap, dc, dt, target, cmd.GetExtraVars())
+		if errD != nil {
+			Logger.Errorf("Error: Scheduled deploy failed -> %s", errD.Error())
+		} else {
+			Logger.Warn("Scheduled deploy procedure complete!!")
+		}
+		Logger.Warnf("Scheduled deploy elapsed time: %s", deploymentClock.Now().Sub(start).String())
+	}, stopOnSignal())
+	return nil
+}
+
+// Runs the deploy and re-runs it any time the main feed, the imported / included feeds or the configuration folder
+// files change, until the process is stopped
+func runContinuousDeployment(config *module.DeployConfig, target string) error {
+	var watcher *schedule.Watcher = schedule.NewWatcher(deploymentClock, continuousPollInterval, continuousDebounce)
+	var stop <-chan struct{} = stopOnSignal()
+	for {
+		var boostrap cmd.Bootstrap = cmd.NewBootStrap()
+		dc, dt, errC := loadConfiguration(boostrap, config)
+		var files []string = make([]string, 0)
+		if errC != nil {
+			Logger.Errorf("Error: %s", errC.Error())
+			files = append(files, listFolderFiles(config.ConfigDir)...)
+		} else if dt.DeploymentType != module.FILE_SOURCE {
+			return errors.New(fmt.Sprintf("Strategy %v requires %v deployment type, found: %v", module.CONTINUOUS_DEPLOYMENT, module.FILE_SOURCE, dt.DeploymentType))
+		} else {
+			files = append(files, listFolderFiles(dc.ConfigDir)...)
+			var source *generic.TrackedFeedSource = generic.NewTrackedFeedSource(generic.NewFileFeedSource())
+			errD := runFeed(boostrap, source, dc.WorkDir+io.GetPathSeparator()+target, cmd.RunOverrides{ExtraVars: cmd.GetExtraVars()})
+			if errD != nil {
+				Logger.Errorf("Error: Continuous deploy failed -> %s", errD.Error())
+			} else {
+				Logger.Warn("Deploy procedure complete!!")
+			}
+			files = append(files, source.GetLocations()...)
+		}
+		watcher.SetFiles(utils.StringSliceUnique(files))
+		Logger.Warnf("Watching %v file(s) for changes ...", len(watcher.GetFiles()))
+		for _, file := range watcher.GetFiles() {
+			Logger.Debugf("- %s", file)
+		}
+		changed, ok := watcher.Watch(stop)
+		if !ok {
+			return nil
+		}
+		for _, file := range changed {
+			Logger.Warnf("Change detected in file: %s", color.Yellow.Render(file))
+		}
+		Logger.Warn("Re-running the deploy ...")
+	}
+}
+
+// Runs the deploy daemon, that keeps configuration and host connections loaded and runs the feeds triggered via the
+// daemon API, until the process is stopped
+func runOnDemandDeployment(boostrap cmd.Bootstrap, dc *module.DeployConfig, dt *module.DeployType, defaultFeed string) error {
+	if dt.DeploymentType == module.PIPE_SOURCE {
+		return errors.New(fmt.Sprintf("Strategy %v doesn't support %v deployment type", module.ON_DEMAND_DEPLOYMENT, dt.DeploymentType))
+	}
+	worker.KeepConnections = true
+	defer worker.CloseConnections()
+	var systemLogger log.Logger = Logger
+	var server *daemon.Server = daemon.NewServer(defaultFeed, func(feed string, vars []defaults.NameValue, logger log.Logger) error {
+		if dt.DeploymentType == module.FILE_SOURCE && !isRelativeFeedPath(feed) {
+			return errors.New(fmt.Sprintf("Feed must be a path relative to the work folder: %s", feed))
+		}
+		// The default feed is configured, triggered ones can't leave the deploy type base url
+		if (dt.DeploymentType == module.HTTP_SOURCE || dt.DeploymentType == module.REST_SOURCE) && feed != defaultFeed {
+			if err := checkTriggeredFeedUrl(dt, feed); err != nil {
+				return err
+			}
+		}
+		// Packages loggers are globals: swapping them is safe only because the daemon server executes a single run
+		// at a time, concurrent runs would require passing the logger through the deploy procedure
+		useLogger(logger)
+		defer useLogger(systemLogger)
+		// Request variables override the command line extra variables
+		return runDeployment(boostrap, dc, dt, feed, append(cmd.GetExtraVars(), vars...))
+	})
+	return server.ListenAndServe(dt.Listen, stopOnSignal())
+}
+
+// Verifies that a feed path is relative and doesn't leave the containing folder
+func isRelativeFeedPath(feed string) bool {
+	var path string = filepath.Clean(feed)
+	return !filepath.IsAbs(path) && path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+// Lists recursively the files in a folder
+func listFolderFiles(folder string) []string {
+	var files []string = make([]string, 0)
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/hellgate75/go-tcp-common/io"
 	"github.com/hellgate75/go-deploy/types/module"
+	"strings"
 )
 
 type ConfigPattern struct {
@@ -26,6 +27,21 @@ func (nv *NameValue) String() string {
 		nv.Name, nv.Value)
 }
 
+// Merges the extra variables over the given ones, replacing the values of variables with the same name
+func MergeVars(vars []NameValue, extraVars []NameValue) []NameValue {
+	var varList []NameValue = make([]NameValue, 0)
+	var indexes map[string]int = make(map[string]int)
+	for _, variable := range append(append([]NameValue{}, vars...), extraVars...) {
+		if idx, ok := indexes[variable.Name]; ok {
+			varList[idx] = variable
+		} else {
+			indexes[variable.Name] = len(varList)
+			varList = append(varList, variable)
+		}
+	}
+	return varList
+}
+
 type Vars struct {
 	Vars []NameValue `yaml:"vars,omitempty" json:"vars,omitempty" xml:"vars,chardata,omitempty"`
 }
@@ -195,8 +211,12 @@ func (envs *Environments) FromJsonCode(jsonCode string) (*Environments, error) {
 }
 
 type HostGroups struct {
-	Name  string      `yaml:"name" json:"name" xml:"name,chardata"`
-	Hosts []HostValue `yaml:"hosts" json:"hosts" xml:"hosts,chardata"`
+	Name       string           `yaml:"name" json:"name" xml:"name,chardata"`
+	Hosts      []HostValue      `yaml:"hosts" json:"hosts" xml:"hosts,chardata"`
+	JumpHost   *JumpHostValue   `yaml:"jumpHost,omitempty" json:"jumpHost,omitempty" xml:"jump-host,omitempty"`
+	Connection *ConnectionValue `yaml:"connection,omitempty" json:"connection,omitempty" xml:"connection,omitempty"`
+	Vars       []NameValue      `yaml:"vars,omitempty" json:"vars,omitempty" xml:"vars,chardata,omitempty"`
+	Children   []string         `yaml:"children,omitempty" json:"children,omitempty" xml:"children,chardata,omitempty"`
 }
 
 func (hg *HostGroups) String() string {
@@ -209,21 +229,162 @@ func (hg *HostGroups) String() string {
 		hostsVal += prefix + host.String()
 	}
 	hostsVal += "]"
-	return fmt.Sprintf("HostValue{Name: \"%s\", Hosts: \"%v\"}",
-		hg.Name, hostsVal)
+	return fmt.Sprintf("HostValue{Name: \"%s\", Hosts: \"%v\", JumpHost: %v, Connection: %v, Vars: %v, Children: %v}",
+		hg.Name, hostsVal, hg.JumpHost, hg.Connection, hg.Vars, hg.Children)
 }
 
 type HostValue struct {
-	Name      string   `yaml:"name" json:"name" xml:"name,chardata"`
-	IpAddress string   `yaml:"ipAddress,omitempty" json:"ipAddress,omitempty" xml:"ip-address,chardata,omitempty"`
-	HostName  string   `yaml:"hostName,omitempty" json:"hostName,omitempty" xml:"host-name,chardata,omitempty"`
-	Port      string   `yaml:"port,omitempty" json:"port,omitempty" xml:"port,chardata,omitempty"`
-	Roles     []string `yaml:"roles,omitempty" json:"roles,omitempty" xml:"roles,chardata,omitempty"`
+	Name         string           `yaml:"name" json:"name" xml:"name,chardata"`
+	IpAddress    string           `yaml:"ipAddress,omitempty" json:"ipAddress,omitempty" xml:"ip-address,chardata,omitempty"`
+	HostName     string           `yaml:"hostName,omitempty" json:"hostName,omitempty" xml:"host-name,chardata,omitempty"`
+	Port         string           `yaml:"port,omitempty" json:"port,omitempty" xml:"port,chardata,omitempty"`
+	Roles        []string         `yaml:"roles,omitempty" json:"roles,omitempty" xml:"roles,chardata,omitempty"`
+	JumpHost     *JumpHostValue   `yaml:"jumpHost,omitempty" json:"jumpHost,omitempty" xml:"jump-host,omitempty"`
+	Fingerprints []string         `yaml:"fingerprints,omitempty" json:"fingerprints,omitempty" xml:"fingerprints,chardata,omitempty"`
+	Connection   *ConnectionValue `yaml:"connection,omitempty" json:"connection,omitempty" xml:"connection,omitempty"`
+	Vars         []NameValue      `yaml:"vars,omitempty" json:"vars,omitempty" xml:"vars,chardata,omitempty"`
+	// Name of the host group the host belongs to, when selected among hosts of different groups
+	Group string `yaml:"-" json:"-" xml:"-"`
 }
 
 func (hv *HostValue) String() string {
-	return fmt.Sprintf("HostValue{Name: \"%s\", IpAddress: \"%s\", HostName: \"%s\", Roles: %v}",
-		hv.Name, hv.IpAddress, hv.HostName, hv.Roles)
+	return fmt.Sprintf("HostValue{Name: \"%s\", IpAddress: \"%s\", HostName: \"%s\", Roles: %v, JumpHost: %v, Fingerprints: %v, Connection: %v, Vars: %v}",
+		hv.Name, hv.IpAddress, hv.HostName, hv.Roles, hv.JumpHost, hv.Fingerprints, hv.Connection, hv.Vars)
+}
+
+// Connection settings of a host group or a host, overriding the network configuration ones
+type ConnectionValue struct {
+	Protocol    module.NetProtocolTypeValue `yaml:"protocol,omitempty" json:"protocol,omitempty" xml:"protocol,chardata,omitempty"`
+	UserName    string                      `yaml:"userName,omitempty" json:"userName,omitempty" xml:"username,chardata,omitempty"`
+	Password    string                      `yaml:"password,omitempty" json:"password,omitempty" xml:"password,chardata,omitempty"`
+	KeyFile     string                      `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
+	Passphrase  string                      `yaml:"passphrase,omitempty" json:"passphrase,omitempty" xml:"passphrase,chardata,omitempty"`
+	Certificate string                      `yaml:"certificate,omitempty" json:"certificate,omitempty" xml:"certificate,chardata,omitempty"`
+	CaCert      string                      `yaml:"caCert,omitempty" json:"caCert,omitempty" xml:"ca-cert,chardata,omitempty"`
+	Insecure    *bool                       `yaml:"insecure,omitempty" json:"insecure,omitempty" xml:"insecure,chardata,omitempty"`
+}
+
+// Retrieves a copy of the network configuration with the defined connection settings, a nil connection retrieves the network configuration.
+// Defined password, key file or certificate replace all the network configuration credentials
+func (cv *ConnectionValue) Apply(netConfig *module.NetProtocolType) *module.NetProtocolType {
+	if cv == nil {
+		return netConfig
+	}
+	var out module.NetProtocolType = module.NetProtocolType{}
+	if netConfig != nil {
+		out = *netConfig
+	}
+	if cv.Protocol != "" {
+		out.NetProtocol = module.NetProtocolTypeValue(strings.ToUpper(string(cv.Protocol)))
+	}
+	if cv.UserName != "" {
+		out.UserName = cv.UserName
+	}
+	if cv.Password != "" || cv.KeyFile != "" || cv.Certificate != "" {
+		// Defined credentials replace all the inherited ones
+		out.Password = ""
+		out.KeyFile = ""
+		out.Passphrase = ""
+		out.Certificate = ""
+		out.CaCert = ""
+	}
+	if cv.Password != "" {
+		out.Password = cv.Password
+	}
+	if cv.KeyFile != "" {
+		out.KeyFile = cv.KeyFile
+	}
+	if cv.Passphrase != "" {
+		out.Passphrase = cv.Passphrase
+	}
+	if cv.Certificate != "" {
+		out.Certificate = cv.Certificate
+	}
+	if cv.CaCert != "" {
+		out.CaCert = cv.CaCert
+	}
+	if cv.Insecure != nil {
+		out.Insecure = *cv.Insecure
+	}
+	return &out
+}
+
+// Retrieves the connection settings overridden by the given ones, a nil connection retrieves the other one.
+// Defined password, key file or certificate replace all the credentials
+func (cv *ConnectionValue) Merge(over *ConnectionValue) *ConnectionValue {
+	if cv == nil {
+		return over
+	}
+	if over == nil {
+		return cv
+	}
+	var out ConnectionValue = *cv
+	if over.Protocol != "" {
+		out.Protocol = over.Protocol
+	}
+	if over.UserName != "" {
+		out.UserName = over.UserName
+	}
+	if over.Password != "" || over.KeyFile != "" || over.Certificate != "" {
+		out.Password = over.Password
+		out.KeyFile = over.KeyFile
+		out.Passphrase = over.Passphrase
+		out.Certificate = over.Certificate
+		out.CaCert = over.CaCert
+	} else {
+		if over.Passphrase != "" {
+			out.Passphrase = over.Passphrase
+		}
+		if over.CaCert != "" {
+			out.CaCert = over.CaCert
+		}
+	}
+	if over.Insecure != nil {
+		out.Insecure = over.Insecure
+	}
+	return &out
+}
+
+func (cv *ConnectionValue) String() string {
+	if cv == nil {
+		return "nil"
+	}
+	var insecure string = "<inherited>"
+	if cv.Insecure != nil {
+		insecure = fmt.Sprintf("%v", *cv.Insecure)
+	}
+	return fmt.Sprintf("ConnectionValue{Protocol: \"%v\", UserName: \"%s\", KeyFile: \"%s\", Certificate: \"%s\", CaCert: \"%s\", Insecure: %s}",
+		cv.Protocol, cv.UserName, cv.KeyFile, cv.Certificate, cv.CaCert, insecure)
+}
+
+// Jump host (bastion) used to reach a host, with its own credentials.
+// Jump hosts are chained defining the jump host used to reach the jump host itself
+type JumpHostValue struct {
+	Address      string         `yaml:"address" json:"address" xml:"address,chardata"`
+	Port         string         `yaml:"port,omitempty" json:"port,omitempty" xml:"port,chardata,omitempty"`
+	UserName     string         `yaml:"userName,omitempty" json:"userName,omitempty" xml:"username,chardata,omitempty"`
+	Password     string         `yaml:"password,omitempty" json:"password,omitempty" xml:"password,chardata,omitempty"`
+	KeyFile      string         `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
+	Passphrase   string         `yaml:"passphrase,omitempty" json:"passphrase,omitempty" xml:"passphrase,chardata,omitempty"`
+	JumpHost     *JumpHostValue `yaml:"jumpHost,omitempty" json:"jumpHost,omitempty" xml:"jump-host,omitempty"`
+	Fingerprints []string       `yaml:"fingerprints,omitempty" json:"fingerprints,omitempty" xml:"fingerprints,chardata,omitempty"`
+}
+
+// Retrieves the jump hosts chain in connection order, from the outermost jump host to this one
+func (jhv *JumpHostValue) Chain() []JumpHostValue {
+	var out []JumpHostValue = make([]JumpHostValue, 0)
+	for jump := jhv; jump != nil; jump = jump.JumpHost {
+		out = append([]JumpHostValue{*jump}, out...)
+	}
+	return out
+}
+
+func (jhv *JumpHostValue) String() string {
+	if jhv == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("JumpHostValue{Address: \"%s\", Port: \"%s\", UserName: \"%s\", KeyFile: \"%s\", JumpHost: %v}",
+		jhv.Address, jhv.Port, jhv.UserName, jhv.KeyFile, jhv.JumpHost)
 }
 
 type HostGroupsConfig struct {
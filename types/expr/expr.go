@@ -0,0 +1,245 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Variables lookup function, it returns false when the variable is not defined
+type Lookup func(name string) (string, bool)
+
+// Boolean expression over variables, e.g.: env == "sit" and os_name contains "Ubuntu".
+// Supported operators are: ==, !=, <, <=, >, >=, contains, startsWith, endsWith, in, matches, and (&&), or (||),
+// not (!), operands are quoted strings, numbers, true, false, list literals ([ "a", "b" ]) and variable names.
+// Undefined variables are evaluated as empty strings, that are false when used as conditions
+type Expression struct {
+	text string
+	root node
+}
+
+// Evaluates the expression, reading variables via the given lookup function
+func (expression *Expression) Evaluate(lookup Lookup) (bool, error) {
+	if lookup == nil {
+		lookup = func(name string) (string, bool) {
+			return "", false
+		}
+	}
+	val, err := expression.root.eval(lookup)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("Unable to evaluate expression: %s -> %s", expression.text, err.Error()))
+	}
+	return toBool(val), nil
+}
+
+// Retrieves the expression text
+func (expression *Expression) String() string {
+	return expression.text
+}
+
+// Parses a boolean expression
+func Parse(text string) (*Expression, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("Empty expression")
+	}
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid expression: %s -> %s", text, err.Error()))
+	}
+	var p *parser = &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEnd {
+		err = errors.New(fmt.Sprintf("Unexpected token '%s' at position %v", p.peek().text, p.peek().position))
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid expression: %s -> %s", text, err.Error()))
+	}
+	return &Expression{
+		text: text,
+		root: root,
+	}, nil
+}
+
+// Combines expressions texts in a single expression text, where all of them must be true
+func And(texts ...string) string {
+	var parts []string = make([]string, 0)
+	for _, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	var out string = ""
+	for _, part := range parts {
+		if out != "" {
+			out += " and "
+		}
+		out += "(" + part + ")"
+	}
+	return out
+}
+
+// Evaluated values are string, bool or []string
+type value interface{}
+
+type node interface {
+	eval(lookup Lookup) (value, error)
+}
+
+type literalNode struct {
+	val value
+}
+
+func (n *literalNode) eval(lookup Lookup) (value, error) {
+	return n.val, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(lookup Lookup) (value, error) {
+	val, _ := lookup(n.name)
+	return val, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(lookup Lookup) (value, error) {
+	var list []string = make([]string, 0)
+	for _, item := range n.items {
+		val, err := item.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, toString(val))
+	}
+	return list, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(lookup Lookup) (value, error) {
+	val, err := n.operand.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	return !toBool(val), nil
+}
+
+type binaryNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *binaryNode) eval(lookup Lookup) (value, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "and" && !toBool(left) {
+		return false, nil
+	}
+	if n.operator == "or" && toBool(left) {
+		return true, nil
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator {
+	case "and", "or":
+		return toBool(right), nil
+	case "==":
+		return compare(left, right) == 0, nil
+	case "!=":
+		return compare(left, right) != 0, nil
+	case "<":
+		return compare(left, right) < 0, nil
+	case "<=":
+		return compare(left, right) <= 0, nil
+	case ">":
+		return compare(left, right) > 0, nil
+	case ">=":
+		return compare(left, right) >= 0, nil
+	case "contains":
+		return contains(left, right), nil
+	case "in":
+		return contains(right, left), nil
+	case "startswith":
+		return strings.HasPrefix(toString(left), toString(right)), nil
+	case "endswith":
+		return strings.HasSuffix(toString(left), toString(right)), nil
+	case "matches":
+		re, err := regexp.Compile(toString(right))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(left)), nil
+	}
+	return nil, errors.New("Unknown operator: " + n.operator)
+}
+
+func toBool(val value) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case []string:
+		return len(v) > 0
+	case string:
+		var lower string = strings.ToLower(strings.TrimSpace(v))
+		return lower != "" && lower != "false" && lower != "0" && lower != "no"
+	}
+	return false
+}
+
+func toString(val value) string {
+	switch v := val.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, ",")
+	case string:
+		return v
+	}
+	return ""
+}
+
+// Compares values as numbers, when both are numeric, or as strings
+func compare(left value, right value) int {
+	var leftText string = toString(left)
+	var rightText string = toString(right)
+	leftNumber, errL := strconv.ParseFloat(strings.TrimSpace(leftText), 64)
+	rightNumber, errR := strconv.ParseFloat(strings.TrimSpace(rightText), 64)
+	if errL == nil && errR == nil {
+		if leftNumber < rightNumber {
+			return -1
+		} else if leftNumber > rightNumber {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(leftText, rightText)
+}
+
+// Verifies if a list contains an item or a string contains a sub-string
+func contains(container value, item value) bool {
+	if list, ok := container.([]string); ok {
+		for _, element := range list {
+			if compare(element, item) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(toString(container), toString(item))
+}
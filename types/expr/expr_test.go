@@ -0,0 +1,181 @@
+package expr
+
+import (
+	"testing"
+)
+
+var testVars map[string]string = map[string]string{
+	"env":     "sit",
+	"os_name": "Ubuntu 20.04",
+	"version": "10",
+	"count":   "9",
+	"enabled": "true",
+	"empty":   "",
+}
+
+func testLookup(name string) (string, bool) {
+	val, ok := testVars[name]
+	return val, ok
+}
+
+func evaluate(t *testing.T, text string) bool {
+	t.Helper()
+	expression, err := Parse(text)
+	if err != nil {
+		t.Fatalf("Unable to parse %s: %v", text, err)
+	}
+	result, err := expression.Evaluate(testLookup)
+	if err != nil {
+		t.Fatalf("Unable to evaluate %s: %v", text, err)
+	}
+	return result
+}
+
+func TestPrecedence(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected bool
+	}{
+		// and binds tighter than or
+		{`true or false and false`, true},
+		{`(true or false) and false`, false},
+		{`false and false or true`, true},
+		{`false and (false or true)`, false},
+		// not binds tighter than and/or, looser than comparisons
+		{`not env == "prod"`, true},
+		{`not env == "sit" or true`, true},
+		{`not (env == "sit" or true)`, false},
+		{`not true and false`, false},
+		{`not false and true`, true},
+		{`not not true`, true},
+		{`! false && true || false`, true},
+		{`env == "sit" and os_name contains "Ubuntu" or env == "prod"`, true},
+		{`env == "prod" or env == "uat" and enabled`, false},
+		{`((env == "sit"))`, true},
+	} {
+		if result := evaluate(t, test.text); result != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.text, test.expected, result)
+		}
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected bool
+	}{
+		// Numbers compare numerically
+		{`version > count`, true},
+		{`version > 9`, true},
+		{`"10" > "9"`, true},
+		{`version == 10.0`, true},
+		{`-1 < 0`, true},
+		{`count >= 9 and count <= 9`, true},
+		// Strings compare lexically, also when only one side is a number
+		{`"b" > "a"`, true},
+		{`"abc" < "abd"`, true},
+		{`"10a" > "9"`, false},
+		{`env != "SIT"`, true},
+		{`env == 'sit'`, true},
+		{`os_name startsWith "Ubuntu"`, true},
+		{`os_name endsWith "04"`, true},
+		{`os_name contains "Debian"`, false},
+		{`os_name matches "^Ubuntu [0-9]+\\.04$"`, true},
+		{`env in ["sit", "uat"]`, true},
+		{`env in ["prod"]`, false},
+		{`version in [9, 10]`, true},
+		{`["a", "b"] contains "b"`, true},
+		{`env IN ["sit"] AND os_name CONTAINS "Ubuntu"`, true},
+		// Conditions without operators
+		{`enabled`, true},
+		{`"no"`, false},
+		{`0`, false},
+		{`[]`, false},
+	} {
+		if result := evaluate(t, test.text); result != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.text, test.expected, result)
+		}
+	}
+}
+
+func TestUndefinedVariables(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected bool
+	}{
+		{`missing`, false},
+		{`not missing`, true},
+		{`missing == ""`, true},
+		{`missing == empty`, true},
+		{`missing != "sit"`, true},
+		{`missing contains "a"`, false},
+		{`missing in ["sit"]`, false},
+		{`missing or env == "sit"`, true},
+	} {
+		if result := evaluate(t, test.text); result != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.text, test.expected, result)
+		}
+	}
+	// Without lookup function all variables are undefined
+	expression, err := Parse(`env == ""`)
+	if err != nil {
+		t.Fatalf("Unable to parse expression: %v", err)
+	}
+	if result, err := expression.Evaluate(nil); err != nil || !result {
+		t.Fatalf("Expected true without lookup, got %v %v", result, err)
+	}
+}
+
+func TestMalformedExpressions(t *testing.T) {
+	for _, text := range []string{
+		``,
+		`   `,
+		`env ==`,
+		`== "sit"`,
+		`env == "sit`,
+		`(env == "sit"`,
+		`env == "sit")`,
+		`env "sit"`,
+		`env == == "sit"`,
+		`env and`,
+		`not`,
+		`env in ["sit", "uat"`,
+		`env in ["sit" "uat"]`,
+		`env in [`,
+		`env # "sit"`,
+		`env = "sit"`,
+		`()`,
+	} {
+		expression, err := Parse(text)
+		if err == nil {
+			t.Errorf("%q: expected a parse error, got %v", text, expression)
+		}
+	}
+	// Invalid regular expressions fail the evaluation
+	expression, err := Parse(`env matches "(["`)
+	if err != nil {
+		t.Fatalf("Unable to parse expression: %v", err)
+	}
+	if _, err = expression.Evaluate(testLookup); err == nil {
+		t.Fatal("Expected an evaluation error")
+	}
+}
+
+func TestAnd(t *testing.T) {
+	for _, test := range []struct {
+		texts    []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"a"}, "a"},
+		{[]string{"", "a", " "}, "a"},
+		{[]string{"a or b", "c"}, "(a or b) and (c)"},
+	} {
+		if result := And(test.texts...); result != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.texts, test.expected, result)
+		}
+	}
+	if !evaluate(t, And(`env == "prod" or true`, `enabled`)) {
+		t.Fatal("Expected combined expression true")
+	}
+}
@@ -0,0 +1,148 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokenEnd tokenType = iota
+	tokenString
+	tokenNumber
+	tokenIdentifier
+	tokenOperator
+	tokenOpenParenthesis
+	tokenCloseParenthesis
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenComma
+)
+
+type token struct {
+	kind     tokenType
+	text     string
+	position int
+}
+
+// Keyword operators, matched case insensitively
+var keywords map[string]string = map[string]string{
+	"and":        "and",
+	"or":         "or",
+	"not":        "not",
+	"in":         "in",
+	"contains":   "contains",
+	"startswith": "startswith",
+	"endswith":   "endswith",
+	"matches":    "matches",
+}
+
+// Symbolic operators, longest first
+var symbols []string = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"}
+
+// Symbolic operators aliases of keyword operators
+var symbolAliases map[string]string = map[string]string{
+	"&&": "and",
+	"||": "or",
+	"!":  "not",
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '.' || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func tokenize(text string) ([]token, error) {
+	var tokens []token = make([]token, 0)
+	var i int = 0
+	for i < len(text) {
+		var c byte = text[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			i++
+			continue
+		}
+		var start int = i
+		if c == '"' || c == '\'' {
+			var value strings.Builder
+			i++
+			var closed bool = false
+			for i < len(text) {
+				if text[i] == '\\' && i+1 < len(text) {
+					value.WriteByte(text[i+1])
+					i += 2
+					continue
+				}
+				if text[i] == c {
+					closed = true
+					i++
+					break
+				}
+				value.WriteByte(text[i])
+				i++
+			}
+			if !closed {
+				return nil, errors.New(fmt.Sprintf("Unterminated string at position %v", start))
+			}
+			tokens = append(tokens, token{tokenString, value.String(), start})
+		} else if isDigit(c) || (c == '-' && i+1 < len(text) && isDigit(text[i+1])) {
+			i++
+			for i < len(text) && (isDigit(text[i]) || text[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, text[start:i], start})
+		} else if isIdentifierStart(c) {
+			for i < len(text) && isIdentifierPart(text[i]) {
+				i++
+			}
+			var word string = text[start:i]
+			if keyword, ok := keywords[strings.ToLower(word)]; ok {
+				tokens = append(tokens, token{tokenOperator, keyword, start})
+			} else {
+				tokens = append(tokens, token{tokenIdentifier, word, start})
+			}
+		} else if c == '(' {
+			tokens = append(tokens, token{tokenOpenParenthesis, "(", start})
+			i++
+		} else if c == ')' {
+			tokens = append(tokens, token{tokenCloseParenthesis, ")", start})
+			i++
+		} else if c == '[' {
+			tokens = append(tokens, token{tokenOpenBracket, "[", start})
+			i++
+		} else if c == ']' {
+			tokens = append(tokens, token{tokenCloseBracket, "]", start})
+			i++
+		} else if c == ',' {
+			tokens = append(tokens, token{tokenComma, ",", start})
+			i++
+		} else {
+			var found bool = false
+			for _, symbol := range symbols {
+				if strings.HasPrefix(text[i:], symbol) {
+					var operator string = symbol
+					if alias, ok := symbolAliases[symbol]; ok {
+						operator = alias
+					}
+					tokens = append(tokens, token{tokenOperator, operator, start})
+					i += len(symbol)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, errors.New(fmt.Sprintf("Unexpected character '%c' at position %v", c, start))
+			}
+		}
+	}
+	tokens = append(tokens, token{tokenEnd, "", len(text)})
+	return tokens, nil
+}
@@ -0,0 +1,142 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Recursive descent parser, operators precedence from lowest: or, and, not, comparisons
+type parser struct {
+	tokens []token
+	index  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	var t token = p.tokens[p.index]
+	if t.kind != tokenEnd {
+		p.index++
+	}
+	return t
+}
+
+func (p *parser) isOperator(operators ...string) bool {
+	var t token = p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, operator := range operators {
+		if t.text == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{"or", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{"and", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOperator("not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOperator("==", "!=", "<", "<=", ">", ">=", "contains", "in", "startswith", "endswith", "matches") {
+		var operator string = p.next().text
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{operator, left, right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	var t token = p.next()
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return &literalNode{t.text}, nil
+	case tokenIdentifier:
+		if t.text == "true" || t.text == "false" {
+			return &literalNode{t.text == "true"}, nil
+		}
+		return &variableNode{t.text}, nil
+	case tokenOpenParenthesis:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseParenthesis {
+			return nil, errors.New(fmt.Sprintf("Missing ')' for '(' at position %v", t.position))
+		}
+		return inner, nil
+	case tokenOpenBracket:
+		var items []node = make([]node, 0)
+		if p.peek().kind == tokenCloseBracket {
+			p.next()
+			return &listNode{items}, nil
+		}
+		for {
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			var sep token = p.next()
+			if sep.kind == tokenCloseBracket {
+				return &listNode{items}, nil
+			}
+			if sep.kind != tokenComma {
+				return nil, errors.New(fmt.Sprintf("Expected ',' or ']' at position %v", sep.position))
+			}
+		}
+	case tokenEnd:
+		return nil, errors.New("Unexpected end of expression")
+	}
+	return nil, errors.New(fmt.Sprintf("Unexpected token '%s' at position %v", t.text, t.position))
+}
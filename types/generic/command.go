@@ -12,17 +12,17 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 )
 
 var Logger log.Logger = nil
 
 func (oset *OptionsSet) Load(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	var data []byte
-	data, err = ioutil.ReadAll(file)
+	return oset.LoadFrom(NewFileFeedSource(), path)
+}
+
+func (oset *OptionsSet) LoadFrom(source FeedSource, location string) error {
+	data, err := source.Read(location)
 	if err != nil {
 		return err
 	}
@@ -38,6 +38,8 @@ func (oset *OptionsSet) Load(path string) error {
 	if err != nil {
 		return err
 	}
+	oset.source = source
+	oset.location = location
 	return nil
 }
 
@@ -67,49 +69,31 @@ func (oset *OptionsSet) Save(path string) error {
 }
 
 func (feed OptionsSet) Validate() ([]*module.Step, []error) {
-	var errors []error = make([]error, 0)
-	var steps []*module.Step = make([]*module.Step, 0)
-	for _, command := range feed.Steps {
-		var commandMap = map[interface{}]interface{}(command)
-		var name string = ""
-		if val, ok := commandMap["name"]; ok {
-			name = fmt.Sprintf("%v", val)
-		} else if val, ok := commandMap["NAME"]; ok {
-			name = fmt.Sprintf("%v", val)
-		}
-		for key, value := range command {
-			if key != "name" && key != "NAME" {
-				stepsX, errorsX := EvaluateSteps(name, key, value)
-				for _, stepX := range stepsX {
-					steps = append(steps, stepX)
-				}
-				for _, errX := range errorsX {
-					errors = append(errors, errX)
-				}
-			}
-		}
+	var source FeedSource = feed.source
+	if source == nil {
+		source = NewFileFeedSource()
 	}
-	return steps, errors
+	return validateCommands(source, feed.location, feed.Steps)
 }
 
 //Feed Interface, that describes the available option for the load of the file
 type IFeed interface {
 	Load(path string) error
+	LoadFrom(source FeedSource, location string) error
 	Save(path string) error
 	Validate() (*module.FeedExec, []error)
 }
 
 func (feed *Feed) Load(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	var data []byte
-	data, err = ioutil.ReadAll(file)
+	return feed.LoadFrom(NewFileFeedSource(), path)
+}
+
+func (feed *Feed) LoadFrom(source FeedSource, location string) error {
+	data, err := source.Read(location)
 	if err != nil {
 		return err
 	}
-	dFormat := cmd.GetFileFormatDescritor(path, module.RuntimeDeployConfig.ConfigLang)
+	dFormat := cmd.GetFileFormatDescritor(locationPath(location), module.RuntimeDeployConfig.ConfigLang)
 	if dFormat == module.YAML_DESCRIPTOR {
 		err = yaml.Unmarshal(data, feed)
 	} else if dFormat == module.XML_DESCRIPTOR {
@@ -122,6 +106,8 @@ func (feed *Feed) Load(path string) error {
 	if err != nil {
 		return err
 	}
+	feed.source = source
+	feed.location = location
 	return nil
 }
 
@@ -155,36 +141,52 @@ func (feed Feed) Validate() (*module.FeedExec, []error) {
 	if feed.HostGroup == "" {
 		errorList = append(errorList, errors.New("Uanble to validate a feed without hosts 'group'"))
 	}
-	var steps []*module.Step = make([]*module.Step, 0)
-	for _, command := range feed.Steps {
-		var commandMap = map[interface{}]interface{}(command)
-		var name string = ""
-		if val, ok := commandMap["name"]; ok {
-			name = fmt.Sprintf("%v", val)
-		} else if val, ok := commandMap["NAME"]; ok {
-			name = fmt.Sprintf("%v", val)
-		}
-		for key, value := range command {
-			if key != "name" && key != "NAME" {
-				stepsX, errorsX := EvaluateSteps(name, key, value)
-				for _, stepX := range stepsX {
-					steps = append(steps, stepX)
-				}
-				for _, errX := range errorsX {
-					errorList = append(errorList, errX)
-				}
-			}
+	var source FeedSource = feed.source
+	if source == nil {
+		source = NewFileFeedSource()
+	}
+	var timeout time.Duration = 0
+	if feed.Timeout != "" {
+		var err error
+		timeout, err = parseDuration(feed.Timeout)
+		if err != nil {
+			errorList = append(errorList, errors.New(fmt.Sprintf("Invalid feed timeout -> %s", err.Error())))
 		}
 	}
+	if feed.MaxFailPercentage < 0 || feed.MaxFailPercentage > 100 {
+		errorList = append(errorList, errors.New(fmt.Sprintf("Invalid feed maxFailPercentage %v, expected a value between 0 and 100", feed.MaxFailPercentage)))
+	}
+	serialCount, serialPercentage, err := parseSerial(feed.Serial)
+	if err != nil {
+		errorList = append(errorList, err)
+	}
+	selector, err := parseRoles(feed.Roles)
+	if err != nil {
+		errorList = append(errorList, errors.New(fmt.Sprintf("Invalid feed roles -> %s", err.Error())))
+	}
+	steps, errorsX := validateCommands(source, feed.location, feed.Steps)
+	errorList = append(errorList, errorsX...)
 	return &module.FeedExec{
-		Name:      feed.Name,
-		HostGroup: feed.HostGroup,
-		Steps:     steps,
+		Name:              feed.Name,
+		HostGroup:         feed.HostGroup,
+		Roles:             selector,
+		Steps:             steps,
+		Timeout:           timeout,
+		FailFast:          feed.FailFast,
+		MaxFailPercentage: feed.MaxFailPercentage,
+		SerialCount:       serialCount,
+		SerialPercentage:  serialPercentage,
 	}, errorList
 }
 
 //Internl function that transforms Blob data in list of module.Step Structure pointers
 func EvaluateSteps(name string, key interface{}, value interface{}) ([]*module.Step, []error) {
+	return EvaluateStepsFrom(NewFileFeedSource(), "", name, key, value)
+}
+
+//Internl function that transforms Blob data in list of module.Step Structure pointers, reading imported and included
+//feeds from the given source, relatively to the declaring feed location
+func EvaluateStepsFrom(source FeedSource, location string, name string, key interface{}, value interface{}) ([]*module.Step, []error) {
 	var errorsList []error = make([]error, 0)
 	var steps []*module.Step = make([]*module.Step, 0)
 	var keyType string = fmt.Sprintf("%T", key)
@@ -197,24 +199,19 @@ func EvaluateSteps(name string, key interface{}, value interface{}) ([]*module.S
 			//New Step
 			var keyVal string = fmt.Sprintf("%v", key)
 			Logger.Tracef("valueType: %v", valueType)
-			if strings.Index(valueType, "map[") == 0 {
+			if strings.Index(valueType, "map[") == 0 || strings.ToLower(keyVal) == "import" || strings.ToLower(keyVal) == "include" {
 
 				if strings.ToLower(keyVal) == "import" {
-					if valueType == "[]string" || valueType == "[]interface{}" {
+					if valueType == "[]string" || valueType == "[]interface {}" {
 						var feeds []*module.FeedExec = make([]*module.FeedExec, 0)
-						var arr []string = make([]string, 0)
-						if valueType == "[]string" {
-							for _, str := range value.([]string) {
-								arr = append(arr, str)
-							}
-						} else {
-							for _, iface := range value.([]interface{}) {
-								arr = append(arr, fmt.Sprintf("%v", iface))
+						for _, path := range referencesList(value) {
+							ref, err := source.Resolve(location, path)
+							if err != nil {
+								errorsList = append(errorsList, err)
+								continue
 							}
-						}
-						for _, path := range arr {
 							var cfeed *Feed = &Feed{}
-							err := cfeed.Load(path)
+							err = cfeed.LoadFrom(source, ref)
 							if err != nil {
 								errorsList = append(errorsList, err)
 							} else {
@@ -235,21 +232,16 @@ func EvaluateSteps(name string, key interface{}, value interface{}) ([]*module.S
 					}
 
 				} else if strings.ToLower(keyVal) == "include" {
-					if valueType == "[]string" || valueType == "[]interface{}" {
+					if valueType == "[]string" || valueType == "[]interface {}" {
 						var feeds []*module.FeedExec = make([]*module.FeedExec, 0)
-						var arr []string = make([]string, 0)
-						if valueType == "[]string" {
-							for _, str := range value.([]string) {
-								arr = append(arr, str)
-							}
-						} else {
-							for _, iface := range value.([]interface{}) {
-								arr = append(arr, fmt.Sprintf("%v", iface))
+						for _, path := range referencesList(value) {
+							ref, err := source.Resolve(location, path)
+							if err != nil {
+								errorsList = append(errorsList, err)
+								continue
 							}
-						}
-						for _, path := range arr {
 							var oset *OptionsSet = &OptionsSet{}
-							err := oset.Load(path)
+							err = oset.LoadFrom(source, ref)
 							if err != nil {
 								errorsList = append(errorsList, err)
 							} else {
@@ -270,7 +262,7 @@ func EvaluateSteps(name string, key interface{}, value interface{}) ([]*module.S
 					}
 
 				} else {
-					step, err := NewStep(name, fmt.Sprintf("%v", key), value)
+					step, err := NewStep(name, keyVal, value)
 					if err != nil {
 						errorsList = append(errorsList, err)
 					} else {
@@ -297,6 +289,21 @@ func EvaluateSteps(name string, key interface{}, value interface{}) ([]*module.S
 	return steps, errorsList
 }
 
+// Converts an import / include value in a list of feed references
+func referencesList(value interface{}) []string {
+	var arr []string = make([]string, 0)
+	if list, ok := value.([]string); ok {
+		for _, str := range list {
+			arr = append(arr, str)
+		}
+	} else if list, ok := value.([]interface{}); ok {
+		for _, iface := range list {
+			arr = append(arr, fmt.Sprintf("%v", iface))
+		}
+	}
+	return arr
+}
+
 //Create new empty Feed interface, ready for load and/or save
 func NewFeed(defaultName string) IFeed {
 	return &Feed{
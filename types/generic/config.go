@@ -4,12 +4,21 @@ import ()
 
 // Feed Strcuture that contains row data, It will be parsed and validated becoming a pointer to module.FeedEx (Executable Feed)
 type Feed struct {
-	Name      string                        `yaml:"name,omitempty" json:"name,omitempty" xml:"name,chardata,omitempty"`
-	HostGroup string                        `yaml:"group,omitempty" json:"group,omitempty" xml:"group,chardata,omitempty"`
-	Steps     []map[interface{}]interface{} `yaml:"steps,omitempty" json:"steps,omitempty" xml:"steps,chardata,omitempty"`
+	Name              string                        `yaml:"name,omitempty" json:"name,omitempty" xml:"name,chardata,omitempty"`
+	HostGroup         string                        `yaml:"group,omitempty" json:"group,omitempty" xml:"group,chardata,omitempty"`
+	Steps             []map[interface{}]interface{} `yaml:"steps,omitempty" json:"steps,omitempty" xml:"steps,chardata,omitempty"`
+	Timeout           string                        `yaml:"timeout,omitempty" json:"timeout,omitempty" xml:"timeout,chardata,omitempty"`
+	FailFast          bool                          `yaml:"failFast,omitempty" json:"failFast,omitempty" xml:"fail-fast,chardata,omitempty"`
+	MaxFailPercentage float64                       `yaml:"maxFailPercentage,omitempty" json:"maxFailPercentage,omitempty" xml:"max-fail-percentage,chardata,omitempty"`
+	Serial            interface{}                   `yaml:"serial,omitempty" json:"serial,omitempty" xml:"serial,chardata,omitempty"`
+	Roles             interface{}                   `yaml:"roles,omitempty" json:"roles,omitempty" xml:"roles,chardata,omitempty"`
+	source            FeedSource
+	location          string
 }
 
 // Fragment of Steps blob data, intended to to be converted in Validation phase becoming a list of one or more module.Step
 type OptionsSet struct {
-	Steps []map[interface{}]interface{} `yaml:",omitempty" json:"steps,omitempty" xml:"steps,chardata,omitempty"`
+	Steps    []map[interface{}]interface{} `yaml:",omitempty" json:"steps,omitempty" xml:"steps,chardata,omitempty"`
+	source   FeedSource
+	location string
 }
@@ -14,6 +14,7 @@ func NewStep(name string, stepType string, stepData interface{}) (*module.Step,
 		Name:     name,
 		StepType: stepType,
 		StepData: data,
+		Data:     stepData,
 		Children: make([]*module.Step, 0),
 		Feeds:    make([]*module.FeedExec, 0),
 	}, nil
@@ -29,6 +30,7 @@ func NewStepWtihChildren(name string, stepType string, stepData interface{}, chi
 		Name:     name,
 		StepType: stepType,
 		StepData: data,
+		Data:     stepData,
 		Children: children,
 		Feeds:    make([]*module.FeedExec, 0),
 	}, nil
@@ -0,0 +1,263 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/expr"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/roles"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step keys reserved to the step options, any other key of a step is a module name
+var reservedStepKeys map[string]bool = map[string]bool{
+	"name":         true,
+	"when":         true,
+	"loop":         true,
+	"withitems":    true,
+	"retries":      true,
+	"delay":        true,
+	"backoff":      true,
+	"until":        true,
+	"timeout":      true,
+	"ignoreerrors": true,
+	"roles":        true,
+}
+
+// Default number of retries of steps with an until condition
+const DEFAULT_UNTIL_RETRIES int = 3
+
+// Default wait time before retrying steps with an until condition
+const DEFAULT_UNTIL_DELAY time.Duration = 5 * time.Second
+
+// Options shared by all the steps declared in a feed command
+type stepOptions struct {
+	name         string
+	when         string
+	loop         []string
+	loopVar      string
+	retries      int
+	delay        time.Duration
+	backoff      float64
+	until        string
+	timeout      time.Duration
+	ignoreErrors bool
+	roles        string
+}
+
+func isReservedStepKey(key interface{}) bool {
+	if keyVal, ok := key.(string); ok {
+		return reservedStepKeys[strings.ToLower(keyVal)]
+	}
+	return false
+}
+
+// Reads the reserved keys of a feed command
+func parseStepOptions(command map[interface{}]interface{}) (stepOptions, []error) {
+	var options stepOptions = stepOptions{
+		retries: -1,
+		delay:   -1,
+	}
+	var errorsList []error = make([]error, 0)
+	for key, value := range command {
+		if !isReservedStepKey(key) {
+			continue
+		}
+		var keyVal string = strings.ToLower(fmt.Sprintf("%v", key))
+		if keyVal == "name" {
+			options.name = fmt.Sprintf("%v", value)
+		} else if keyVal == "when" {
+			options.when = fmt.Sprintf("%v", value)
+			if _, err := expr.Parse(options.when); err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid when condition -> %s", options.name, err.Error())))
+			}
+		} else if keyVal == "loop" || keyVal == "withitems" {
+			if list, ok := value.([]interface{}); ok {
+				options.loop = make([]string, 0)
+				for _, item := range list {
+					options.loop = append(options.loop, fmt.Sprintf("%v", item))
+				}
+			} else if name, ok := value.(string); ok && strings.TrimSpace(name) != "" {
+				options.loopVar = strings.TrimSpace(name)
+			} else {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid loop type %T, expected list or variable name", options.name, value)))
+			}
+		} else if keyVal == "retries" {
+			retries, err := strconv.Atoi(fmt.Sprintf("%v", value))
+			if err != nil || retries < 0 {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid retries %v, expected a positive number", options.name, value)))
+			} else {
+				options.retries = retries
+			}
+		} else if keyVal == "delay" {
+			delay, err := parseDuration(value)
+			if err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid delay -> %s", options.name, err.Error())))
+			} else {
+				options.delay = delay
+			}
+		} else if keyVal == "backoff" {
+			backoff, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+			if err != nil || backoff < 1 {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid backoff %v, expected a number not lower than 1", options.name, value)))
+			} else {
+				options.backoff = backoff
+			}
+		} else if keyVal == "timeout" {
+			timeout, err := parseDuration(value)
+			if err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid timeout -> %s", options.name, err.Error())))
+			} else {
+				options.timeout = timeout
+			}
+		} else if keyVal == "ignoreerrors" {
+			ignoreErrors, err := strconv.ParseBool(fmt.Sprintf("%v", value))
+			if err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid ignoreErrors %v, expected true or false", options.name, value)))
+			} else {
+				options.ignoreErrors = ignoreErrors
+			}
+		} else if keyVal == "roles" {
+			selector, err := parseRoles(value)
+			if err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid roles -> %s", options.name, err.Error())))
+			} else {
+				options.roles = selector
+			}
+		} else if keyVal == "until" {
+			options.until = fmt.Sprintf("%v", value)
+			if _, err := expr.Parse(options.until); err != nil {
+				errorsList = append(errorsList, errors.New(fmt.Sprintf("Step %s: invalid until condition -> %s", options.name, err.Error())))
+			}
+		}
+	}
+	if options.until != "" {
+		if options.retries < 0 {
+			options.retries = DEFAULT_UNTIL_RETRIES
+		}
+		if options.delay < 0 {
+			options.delay = DEFAULT_UNTIL_DELAY
+		}
+	}
+	return options, errorsList
+}
+
+// Parses a duration as Go duration string (e.g.: 1m30s) or as number of seconds
+func parseDuration(value interface{}) (time.Duration, error) {
+	var text string = strings.TrimSpace(fmt.Sprintf("%v", value))
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil {
+		if seconds < 0 {
+			return 0, errors.New("Negative duration: " + text)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, errors.New("Negative duration: " + text)
+	}
+	return duration, nil
+}
+
+// Parses a roles selector, as selector text (e.g.: web & !canary) or list of roles, any of them matching
+func parseRoles(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	var text string = ""
+	if list, ok := value.([]interface{}); ok {
+		var names []string = make([]string, 0)
+		for _, item := range list {
+			names = append(names, fmt.Sprintf("%v", item))
+		}
+		text = strings.Join(names, " | ")
+	} else {
+		text = fmt.Sprintf("%v", value)
+	}
+	if _, err := roles.Parse(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// Applies the command options to the steps, options apply to all the steps brought by imported and included feeds
+func applyStepOptions(steps []*module.Step, options stepOptions) {
+	for _, step := range steps {
+		if options.when != "" {
+			step.When = expr.And(options.when, step.When)
+		}
+		if (options.loop != nil || options.loopVar != "") && step.Loop == nil && step.LoopVar == "" {
+			step.Loop = options.loop
+			step.LoopVar = options.loopVar
+		}
+		if options.retries > 0 && step.Retries == 0 {
+			step.Retries = options.retries
+		}
+		if options.delay > 0 && step.Delay == 0 {
+			step.Delay = options.delay
+		}
+		if options.backoff > 0 && step.Backoff == 0 {
+			step.Backoff = options.backoff
+		}
+		if options.until != "" {
+			step.Until = expr.And(options.until, step.Until)
+		}
+		if options.timeout > 0 && step.Timeout == 0 {
+			step.Timeout = options.timeout
+		}
+		if options.ignoreErrors {
+			step.IgnoreErrors = true
+		}
+		if options.roles != "" {
+			step.Roles = roles.And(options.roles, step.Roles)
+		}
+		applyStepOptions(step.Children, options)
+		for _, feed := range step.Feeds {
+			applyStepOptions(feed.Steps, options)
+		}
+	}
+}
+
+// Transforms the feed commands in module.Step pointers, reading imported and included feeds from the given source,
+// relatively to the declaring feed location
+func validateCommands(source FeedSource, location string, commands []map[interface{}]interface{}) ([]*module.Step, []error) {
+	var errorsList []error = make([]error, 0)
+	var steps []*module.Step = make([]*module.Step, 0)
+	for _, command := range commands {
+		options, errorsX := parseStepOptions(command)
+		errorsList = append(errorsList, errorsX...)
+		for key, value := range command {
+			if !isReservedStepKey(key) {
+				stepsX, errorsX := EvaluateStepsFrom(source, location, options.name, key, value)
+				applyStepOptions(stepsX, options)
+				steps = append(steps, stepsX...)
+				errorsList = append(errorsList, errorsX...)
+			}
+		}
+	}
+	return steps, errorsList
+}
+
+// Parses a feed serial value, as number of hosts (e.g.: 2) or percentage of the group hosts (e.g.: 25%)
+func parseSerial(value interface{}) (int, float64, error) {
+	if value == nil {
+		return 0, 0, nil
+	}
+	var text string = strings.TrimSpace(fmt.Sprintf("%v", value))
+	if strings.HasSuffix(text, "%") {
+		percentage, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(text, "%")), 64)
+		if err != nil || percentage <= 0 || percentage > 100 {
+			return 0, 0, errors.New(fmt.Sprintf("Invalid feed serial %s, expected a percentage between 0 and 100", text))
+		}
+		return 0, percentage, nil
+	}
+	count, err := strconv.Atoi(text)
+	if err != nil || count < 0 {
+		return 0, 0, errors.New(fmt.Sprintf("Invalid feed serial %s, expected a number of hosts or a percentage", text))
+	}
+	return count, 0, nil
+}
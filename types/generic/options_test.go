@@ -0,0 +1,35 @@
+package generic
+
+import (
+	"testing"
+)
+
+func TestParseSerial(t *testing.T) {
+	for _, test := range []struct {
+		value      interface{}
+		count      int
+		percentage float64
+	}{
+		{nil, 0, 0},
+		{2, 2, 0},
+		{"3", 3, 0},
+		{" 4 ", 4, 0},
+		{"25%", 0, 25},
+		{"12.5 %", 0, 12.5},
+		{"100%", 0, 100},
+	} {
+		count, percentage, err := parseSerial(test.value)
+		if err != nil {
+			t.Errorf("Unable to parse serial %v: %v", test.value, err)
+			continue
+		}
+		if count != test.count || percentage != test.percentage {
+			t.Errorf("Serial %v: expected %v hosts and %v%%, got %v hosts and %v%%", test.value, test.count, test.percentage, count, percentage)
+		}
+	}
+	for _, value := range []interface{}{"-1", "two", "0%", "101%", "%", "1.5"} {
+		if _, _, err := parseSerial(value); err == nil {
+			t.Errorf("Expected an error parsing serial %v", value)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package generic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+// Reads a stream of feeds, as multi-document YAML or a sequence of JSON objects (new line delimited or spanning multiple
+// lines), and calls back the given function for each document in the order they arrive. Document index starts from 1,
+// and a parse error is given to the callback in place of the feed. Reading stops when the callback returns false or
+// when the stream is over.
+func ReadFeedStream(reader io.Reader, callback func(index int, feed IFeed, err error) bool) error {
+	var buffered *bufio.Reader = bufio.NewReader(reader)
+	var first byte = 0
+	for {
+		peek, err := buffered.Peek(1)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(peek)) != "" {
+			first = peek[0]
+			break
+		}
+		buffered.ReadByte()
+	}
+	if first == '{' {
+		return readJsonFeeds(buffered, callback)
+	}
+	return readYamlDocumentsFeeds(buffered, callback)
+}
+
+func readJsonFeeds(reader *bufio.Reader, callback func(index int, feed IFeed, err error) bool) error {
+	var decoder *json.Decoder = json.NewDecoder(reader)
+	var index int = 0
+	for {
+		index++
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			// A broken JSON stream cannot be resynchronized
+			callback(index, nil, errors.New(fmt.Sprintf("Unable to parse document %v, cause: %s", index, err.Error())))
+			return err
+		}
+		var feed *Feed = &Feed{
+			Name: fmt.Sprintf("document-%v", index),
+		}
+		// JSON is a subset of YAML, and YAML decoder can fill the steps generic maps, compacted JSON has no tab
+		// indentation, that YAML doesn't allow
+		var compact bytes.Buffer
+		var errD error = json.Compact(&compact, raw)
+		if errD == nil {
+			errD = yaml.Unmarshal(compact.Bytes(), feed)
+		}
+		if errD != nil {
+			errD = errors.New(fmt.Sprintf("Unable to parse document %v, cause: %s", index, errD.Error()))
+		}
+		if !callback(index, feed, errD) {
+			return nil
+		}
+	}
+}
+
+func readYamlDocumentsFeeds(reader *bufio.Reader, callback func(index int, feed IFeed, err error) bool) error {
+	var decoder *yaml.Decoder = yaml.NewDecoder(reader)
+	var index int = 0
+	for {
+		index++
+		var feed *Feed = &Feed{
+			Name: fmt.Sprintf("document-%v", index),
+		}
+		err := decoder.Decode(feed)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			// A broken YAML stream cannot be resynchronized
+			callback(index, nil, errors.New(fmt.Sprintf("Unable to parse document %v, cause: %s", index, err.Error())))
+			return err
+		}
+		if !callback(index, feed, nil) {
+			return nil
+		}
+	}
+}
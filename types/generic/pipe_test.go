@@ -0,0 +1,102 @@
+package generic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Reads a feeds stream, describing each document as name/group or as error
+func readStream(t *testing.T, stream string, max int) ([]string, error) {
+	t.Helper()
+	var documents []string = make([]string, 0)
+	err := ReadFeedStream(strings.NewReader(stream), func(index int, feed IFeed, err error) bool {
+		if index != len(documents)+1 {
+			t.Fatalf("Expected document index %v, got %v", len(documents)+1, index)
+		}
+		if err != nil {
+			documents = append(documents, "error")
+		} else {
+			var f *Feed = feed.(*Feed)
+			documents = append(documents, fmt.Sprintf("%s/%s/%v", f.Name, f.HostGroup, len(f.Steps)))
+		}
+		return max <= 0 || len(documents) < max
+	})
+	return documents, err
+}
+
+func TestReadFeedStream(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		stream   string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"blank", " \n\t\n", ""},
+		{
+			"multi-document YAML",
+			"name: first\ngroup: web\nsteps:\n  - name: a\n---\nname: second\ngroup: db\n---\ngroup: cache\n",
+			"first/web/1,second/db/0,document-3/cache/0",
+		},
+		{
+			"YAML JSON document",
+			"---\n{\"name\": \"first\", \"group\": \"web\"}\n",
+			"first/web/0",
+		},
+		{
+			"new line delimited JSON",
+			"{\"name\": \"first\", \"group\": \"web\"}\n{\"name\": \"second\", \"group\": \"db\", \"steps\": [{\"name\": \"a\"}, {\"name\": \"b\"}]}\n\n{\"group\": \"cache\"}",
+			"first/web/0,second/db/2,document-3/cache/0",
+		},
+		{
+			"multi-line JSON",
+			"\n{\n\t\"name\": \"first\",\n\t\"group\": \"web\",\n\t\"steps\": [\n\t\t{\"name\": \"a\"}\n\t]\n}\n{\n  \"name\": \"second\",\n  \"group\": \"db\"\n}{\"name\": \"third\"}\n",
+			"first/web/1,second/db/0,third//0",
+		},
+		{
+			"JSON document not a feed",
+			"{\"name\": \"first\"}\n[\"not\", \"a\", \"feed\"]\n{\"name\": \"third\"}\n",
+			"first//0,error,third//0",
+		},
+	} {
+		documents, err := readStream(t, test.stream, 0)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if result := strings.Join(documents, ","); result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestReadFeedStreamStopsOnBrokenStream(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		stream   string
+		expected string
+	}{
+		{"JSON", "{\"name\": \"first\"}\n{\"name\": \n{\"name\": \"third\"}\n", "first//0,error"},
+		{"YAML", "name: first\n---\nname: [second\n---\nname: third\n", "first//0,error"},
+	} {
+		documents, err := readStream(t, test.stream, 0)
+		if err == nil {
+			t.Errorf("%s: expected a stream error", test.name)
+		}
+		if result := strings.Join(documents, ","); result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestReadFeedStreamStopsWhenCallbackReturnsFalse(t *testing.T) {
+	for _, stream := range []string{
+		"{\"name\": \"first\"}\n{\"name\": \"second\"}\n{\"name\": \"third\"}\n",
+		"name: first\n---\nname: second\n---\nname: third\n",
+	} {
+		documents, err := readStream(t, stream, 2)
+		if err != nil || strings.Join(documents, ",") != "first//0,second//0" {
+			t.Errorf("Expected the first 2 documents, got %v %v", documents, err)
+		}
+	}
+}
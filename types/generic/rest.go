@@ -0,0 +1,68 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Deployment descriptor returned by a REST service, it contains the Feed, the host groups and the variables to use
+type RestFeedEnvelope struct {
+	Feed       Feed                  `yaml:"feed" json:"feed"`
+	HostGroups []defaults.HostGroups `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	Vars       []defaults.NameValue  `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+// Calls the deployment descriptor REST service with given method and body, and parses the returned JSON envelope.
+// Feed imports and includes are resolved against the service url.
+func FetchRestFeedEnvelope(client *http.Client, method module.RestMethodTypeValue, location string, postBody string) (*RestFeedEnvelope, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var request *http.Request
+	var err error
+	method = module.RestMethodTypeValue(strings.ToUpper(strings.TrimSpace(string(method))))
+	if method == "" || method == module.REST_GET_REQUEST {
+		request, err = http.NewRequest(http.MethodGet, location, nil)
+	} else if method == module.REST_POST_REQUEST {
+		request, err = http.NewRequest(http.MethodPost, location, strings.NewReader(postBody))
+		if err == nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		return nil, errors.New(fmt.Sprintf("FetchRestFeedEnvelope: Unsupported rest method: %s", method))
+	}
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, errors.New(fmt.Sprintf("FetchRestFeedEnvelope: Unable to call %s %s, status: %s", request.Method, location, response.Status))
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var envelope *RestFeedEnvelope = &RestFeedEnvelope{}
+	// JSON is a subset of YAML, and YAML decoder can fill the steps generic maps
+	err = yaml.Unmarshal(data, envelope)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("FetchRestFeedEnvelope: Unable to parse response from %s, cause: %s", location, err.Error()))
+	}
+	if envelope.Feed.Name == "" {
+		envelope.Feed.Name = "default"
+	}
+	envelope.Feed.source = NewHttpFeedSource(client, "")
+	envelope.Feed.location = location
+	return envelope, nil
+}
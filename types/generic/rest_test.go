@@ -0,0 +1,235 @@
+package generic
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/cmd"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testEnvelope string = `{
+	"feed": {"name": "release", "group": "web"},
+	"hosts": [{"name": "web", "vars": [{"name": "tier", "value": "frontend"}],
+		"hosts": [{"name": "web-01", "ipAddress": "127.0.0.1"}, {"name": "web-02", "ipAddress": "127.0.0.2", "vars": [{"name": "version", "value": "1.2.1"}]}]}],
+	"vars": [{"name": "version", "value": "1.2.3"}, {"name": "tier", "value": "none"}]
+}`
+
+// Deployment descriptor service answering with a fixed status and body, it records the requests
+type envelopeServer struct {
+	sync.Mutex
+	status   int
+	body     string
+	requests []*http.Request
+	bodies   []string
+}
+
+func (server *envelopeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+	data, _ := ioutil.ReadAll(r.Body)
+	server.requests = append(server.requests, r)
+	server.bodies = append(server.bodies, string(data))
+	w.WriteHeader(server.status)
+	w.Write([]byte(server.body))
+}
+
+func (server *envelopeServer) answer(status int, body string) {
+	server.Lock()
+	defer server.Unlock()
+	server.status = status
+	server.body = body
+}
+
+func newEnvelopeServer(t *testing.T, status int, body string) (*envelopeServer, *httptest.Server) {
+	var server *envelopeServer = &envelopeServer{status: status, body: body}
+	var httpServer *httptest.Server = httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return server, httpServer
+}
+
+func TestFetchRestFeedEnvelopeGet(t *testing.T) {
+	server, httpServer := newEnvelopeServer(t, http.StatusOK, testEnvelope)
+	for _, method := range []module.RestMethodTypeValue{"", module.REST_GET_REQUEST, " get "} {
+		envelope, err := FetchRestFeedEnvelope(httpServer.Client(), method, httpServer.URL+"/releases/42?env=sit", "ignored")
+		if err != nil {
+			t.Fatalf("Method %q: unable to fetch envelope: %v", method, err)
+		}
+		if envelope.Feed.Name != "release" || envelope.Feed.HostGroup != "web" {
+			t.Fatalf("Method %q: unexpected feed %v", method, envelope.Feed)
+		}
+		if len(envelope.HostGroups) != 1 || len(envelope.HostGroups[0].Hosts) != 2 || len(envelope.Vars) != 2 {
+			t.Fatalf("Method %q: unexpected hosts %v and vars %v", method, envelope.HostGroups, envelope.Vars)
+		}
+	}
+	for index, request := range server.requests {
+		if request.Method != http.MethodGet || request.URL.RequestURI() != "/releases/42?env=sit" || server.bodies[index] != "" {
+			t.Fatalf("Unexpected request: %s %s %q", request.Method, request.URL.RequestURI(), server.bodies[index])
+		}
+		if request.Header.Get("Accept") != "application/json" {
+			t.Fatalf("Unexpected Accept header: %s", request.Header.Get("Accept"))
+		}
+	}
+}
+
+func TestFetchRestFeedEnvelopePost(t *testing.T) {
+	server, httpServer := newEnvelopeServer(t, http.StatusCreated, `{"feed": {"group": "web"}}`)
+	envelope, err := FetchRestFeedEnvelope(httpServer.Client(), "post", httpServer.URL+"/releases", `{"release": "1.2.3"}`)
+	if err != nil {
+		t.Fatalf("Unable to fetch envelope: %v", err)
+	}
+	// The feed name defaults when missing, hosts and vars are optional
+	if envelope.Feed.Name != "default" || envelope.HostGroups != nil || envelope.Vars != nil {
+		t.Fatalf("Unexpected envelope: %v", envelope)
+	}
+	if envelope.Feed.location != httpServer.URL+"/releases" {
+		t.Fatalf("Unexpected feed location: %s", envelope.Feed.location)
+	}
+	var request *http.Request = server.requests[0]
+	if request.Method != http.MethodPost || server.bodies[0] != `{"release": "1.2.3"}` {
+		t.Fatalf("Unexpected request: %s %q", request.Method, server.bodies[0])
+	}
+	if request.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected Content-Type header: %s", request.Header.Get("Content-Type"))
+	}
+}
+
+func TestFetchRestFeedEnvelopeErrors(t *testing.T) {
+	server, httpServer := newEnvelopeServer(t, http.StatusNotFound, "release not found")
+	if _, err := FetchRestFeedEnvelope(httpServer.Client(), module.REST_GET_REQUEST, httpServer.URL, ""); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Expected a status error, got %v", err)
+	}
+	server.answer(http.StatusMultipleChoices, "")
+	if _, err := FetchRestFeedEnvelope(httpServer.Client(), module.REST_POST_REQUEST, httpServer.URL, ""); err == nil || !strings.Contains(err.Error(), "300") {
+		t.Fatalf("Expected a status error, got %v", err)
+	}
+	server.answer(http.StatusOK, "feed: [")
+	if _, err := FetchRestFeedEnvelope(httpServer.Client(), module.REST_GET_REQUEST, httpServer.URL, ""); err == nil || !strings.Contains(err.Error(), "Unable to parse") {
+		t.Fatalf("Expected a parse error, got %v", err)
+	}
+	if _, err := FetchRestFeedEnvelope(httpServer.Client(), "DELETE", httpServer.URL, ""); err == nil || !strings.Contains(err.Error(), "Unsupported") {
+		t.Fatalf("Expected a method error, got %v", err)
+	}
+	if len(server.requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %v", len(server.requests))
+	}
+}
+
+// Step runnable recording a session variable value by host
+type varRecorder struct {
+	sync.Mutex
+	name   string
+	values map[string]string
+}
+
+type varRecorderRunnable struct {
+	uuid     string
+	host     defaults.HostValue
+	session  module.Session
+	recorder *varRecorder
+}
+
+var varRecorderCount int = 0
+
+func (runnable *varRecorderRunnable) Run() error {
+	value, _ := runnable.session.GetVar(runnable.recorder.name)
+	runnable.recorder.Lock()
+	defer runnable.recorder.Unlock()
+	runnable.recorder.values[runnable.host.Name] = value
+	return nil
+}
+
+func (runnable *varRecorderRunnable) Stop() error     { return nil }
+func (runnable *varRecorderRunnable) Kill() error     { return nil }
+func (runnable *varRecorderRunnable) Pause() error    { return nil }
+func (runnable *varRecorderRunnable) Resume() error   { return nil }
+func (runnable *varRecorderRunnable) IsRunning() bool { return false }
+func (runnable *varRecorderRunnable) IsPaused() bool  { return false }
+func (runnable *varRecorderRunnable) UUID() string    { return runnable.uuid }
+
+func (runnable *varRecorderRunnable) Clone() threads.StepRunnable {
+	varRecorderCount++
+	return &varRecorderRunnable{
+		uuid:     fmt.Sprintf("var-recorder-%v", varRecorderCount),
+		recorder: runnable.recorder,
+	}
+}
+
+func (runnable *varRecorderRunnable) SetClient(client generic.NetworkClient)  {}
+func (runnable *varRecorderRunnable) SetHost(host defaults.HostValue)         { runnable.host = host }
+func (runnable *varRecorderRunnable) SetSession(session module.Session)       { runnable.session = session }
+func (runnable *varRecorderRunnable) SetConfig(config defaults.ConfigPattern) {}
+
+func (runnable *varRecorderRunnable) Equals(r threads.StepRunnable) bool {
+	return r != nil && r.UUID() == runnable.uuid
+}
+
+func setUpRunTest(t *testing.T) {
+	setUpFeedsTest(t)
+	var deployType *module.DeployType = module.RuntimeDeployType
+	var netType *module.NetProtocolType = module.RuntimeNetworkType
+	var pluginsType *module.PluginsConfig = module.RuntimePluginsType
+	var logger log.Logger = cmd.Logger
+	module.RuntimeDeployConfig.ConfigDir = t.TempDir()
+	module.RuntimeDeployConfig.MaxThreads = 2
+	module.RuntimeDeployType = &module.DeployType{DeploymentType: module.REST_SOURCE}
+	module.RuntimeNetworkType = &module.NetProtocolType{NetProtocol: module.NET_PROTOCOL_LOCAL, UserName: "deploy", Password: "secret"}
+	module.RuntimePluginsType = &module.PluginsConfig{}
+	cmd.Logger = log.NewLogger("test", log.ERROR)
+	t.Cleanup(func() {
+		module.RuntimeDeployType = deployType
+		module.RuntimeNetworkType = netType
+		module.RuntimePluginsType = pluginsType
+		cmd.Logger = logger
+	})
+}
+
+func TestRestFeedEnvelopeHostsAndVarsReachTheRun(t *testing.T) {
+	setUpRunTest(t)
+	_, httpServer := newEnvelopeServer(t, http.StatusOK, testEnvelope)
+	envelope, err := FetchRestFeedEnvelope(httpServer.Client(), module.REST_GET_REQUEST, httpServer.URL, "")
+	if err != nil {
+		t.Fatalf("Unable to fetch envelope: %v", err)
+	}
+	feedEx, errs := envelope.Feed.Validate()
+	if len(errs) > 0 {
+		t.Fatalf("Unable to validate feed: %v", errs)
+	}
+	var versions *varRecorder = &varRecorder{name: "version", values: make(map[string]string)}
+	var tiers *varRecorder = &varRecorder{name: "tier", values: make(map[string]string)}
+	feedEx.Steps = append(feedEx.Steps,
+		&module.Step{Name: "version", StepType: "test", StepData: &varRecorderRunnable{recorder: versions}},
+		&module.Step{Name: "tier", StepType: "test", StepData: &varRecorderRunnable{recorder: tiers}})
+	errs = cmd.NewBootStrap().RunWith(feedEx, cmd.RunOverrides{
+		HostGroups: envelope.HostGroups,
+		Vars:       envelope.Vars,
+		ExtraVars:  []defaults.NameValue{},
+	}, log.NewLogger("test", log.ERROR))
+	if len(errs) > 0 {
+		t.Fatalf("Unable to run feed: %v", errs)
+	}
+	// Envelope hosts run the feed, host and group vars override the envelope vars
+	var hosts []string = make([]string, 0)
+	for host := range versions.values {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	if strings.Join(hosts, ",") != "web-01,web-02" {
+		t.Fatalf("Expected the envelope hosts, got %v", hosts)
+	}
+	if versions.values["web-01"] != "1.2.3" || versions.values["web-02"] != "1.2.1" {
+		t.Fatalf("Unexpected version vars: %v", versions.values)
+	}
+	if tiers.values["web-01"] != "frontend" || tiers.values["web-02"] != "frontend" {
+		t.Fatalf("Unexpected tier vars: %v", tiers.values)
+	}
+}
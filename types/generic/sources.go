@@ -0,0 +1,177 @@
+package generic
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Feed Source interface, that describes how raw feed data is read and how sub-feed references are located
+type FeedSource interface {
+	// Resolves a feed reference (import or include) against the location of the feed that declares it
+	Resolve(parent string, ref string) (string, error)
+	// Reads the raw data of the feed at the given location
+	Read(location string) ([]byte, error)
+}
+
+type fileFeedSource struct {
+}
+
+func (source *fileFeedSource) Resolve(parent string, ref string) (string, error) {
+	return ref, nil
+}
+
+func (source *fileFeedSource) Read(location string) ([]byte, error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// Creates a new file system Feed Source, references are read as given paths
+func NewFileFeedSource() FeedSource {
+	return &fileFeedSource{}
+}
+
+type httpFeedSource struct {
+	client   *http.Client
+	cacheDir string
+}
+
+func (source *httpFeedSource) Resolve(parent string, ref string) (string, error) {
+	refUrl, err := url.Parse(ref)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("HttpFeedSource.Resolve: Invalid reference %s, cause: %s", ref, err.Error()))
+	}
+	if parent == "" || refUrl.IsAbs() {
+		return refUrl.String(), nil
+	}
+	parentUrl, err := url.Parse(parent)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("HttpFeedSource.Resolve: Invalid base url %s, cause: %s", parent, err.Error()))
+	}
+	return parentUrl.ResolveReference(refUrl).String(), nil
+}
+
+func (source *httpFeedSource) Read(location string) ([]byte, error) {
+	var dataFile string = ""
+	var etagFile string = ""
+	var etag string = ""
+	if source.cacheDir != "" {
+		hash := sha1.Sum([]byte(location))
+		var key string = hex.EncodeToString(hash[:])
+		dataFile = filepath.Join(source.cacheDir, key+".data")
+		etagFile = filepath.Join(source.cacheDir, key+".etag")
+		if _, err := os.Stat(dataFile); err == nil {
+			if data, err := ioutil.ReadFile(etagFile); err == nil {
+				etag = string(data)
+			}
+		}
+	}
+	request, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	response, err := source.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified && etag != "" {
+		Logger.Debugf("HttpFeedSource.Read: %s not modified, using cached copy", location)
+		return ioutil.ReadFile(dataFile)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("HttpFeedSource.Read: Unable to download %s, status: %s", location, response.Status))
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if dataFile != "" {
+		var newEtag string = response.Header.Get("ETag")
+		if newEtag != "" {
+			errC := os.MkdirAll(source.cacheDir, 0755)
+			if errC == nil {
+				errC = ioutil.WriteFile(dataFile, data, 0644)
+			}
+			if errC == nil {
+				errC = ioutil.WriteFile(etagFile, []byte(newEtag), 0644)
+			}
+			if errC != nil {
+				Logger.Warnf("HttpFeedSource.Read: Unable to cache %s, cause: %s", location, errC.Error())
+			}
+		} else {
+			os.Remove(dataFile)
+			os.Remove(etagFile)
+		}
+	}
+	return data, nil
+}
+
+// Creates a new HTTP(S) Feed Source, references are resolved against the declaring feed url.
+// Downloaded feeds are cached by ETag in the given cache folder, no caching is done if the folder is empty
+func NewHttpFeedSource(client *http.Client, cacheDir string) FeedSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpFeedSource{
+		client:   client,
+		cacheDir: cacheDir,
+	}
+}
+
+// Retrieves the path that carries the format extension of a location, stripping any url query
+func locationPath(location string) string {
+	if u, err := url.Parse(location); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return u.Path
+	}
+	return location
+}
+
+// Feed Source that records the locations read through a wrapped Feed Source
+type TrackedFeedSource struct {
+	sync.Mutex
+	source    FeedSource
+	locations []string
+}
+
+func (source *TrackedFeedSource) Resolve(parent string, ref string) (string, error) {
+	return source.source.Resolve(parent, ref)
+}
+
+func (source *TrackedFeedSource) Read(location string) ([]byte, error) {
+	source.Lock()
+	source.locations = append(source.locations, location)
+	source.Unlock()
+	return source.source.Read(location)
+}
+
+// Retrieves the locations read so far, in reading order
+func (source *TrackedFeedSource) GetLocations() []string {
+	source.Lock()
+	defer source.Unlock()
+	var locations []string = make([]string, len(source.locations))
+	copy(locations, source.locations)
+	return locations
+}
+
+// Creates a new Feed Source recording the locations read via the given Feed Source
+func NewTrackedFeedSource(source FeedSource) *TrackedFeedSource {
+	return &TrackedFeedSource{
+		source:    source,
+		locations: make([]string, 0),
+	}
+}
@@ -0,0 +1,127 @@
+package generic
+
+import (
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-tcp-common/log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Feeds server, serving the given documents by path with a fixed ETag and answering 304 to matching If-None-Match
+type feedsServer struct {
+	sync.Mutex
+	documents map[string]string
+	requests  []string
+	modified  int
+}
+
+func (server *feedsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+	server.requests = append(server.requests, r.URL.Path)
+	document, ok := server.documents[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var etag string = "\"" + r.URL.Path + "\""
+	if r.Header.Get("If-None-Match") == etag {
+		server.modified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(document))
+}
+
+func setUpFeedsTest(t *testing.T) {
+	var config *module.DeployConfig = module.RuntimeDeployConfig
+	var logger log.Logger = Logger
+	module.RuntimeDeployConfig = &module.DeployConfig{
+		ConfigLang: module.YAML_DESCRIPTOR,
+	}
+	Logger = log.NewLogger("test", log.ERROR)
+	t.Cleanup(func() {
+		module.RuntimeDeployConfig = config
+		Logger = logger
+	})
+}
+
+func TestHttpFeedSourceResolvesRelativeReferences(t *testing.T) {
+	setUpFeedsTest(t)
+	var server *feedsServer = &feedsServer{
+		documents: map[string]string{
+			"/feeds/main.yaml": "name: main\ngroup: web\nsteps:\n" +
+				"  - name: child\n    import: [sub/child.yaml]\n" +
+				"  - name: common\n    include: [../common/steps.yaml]\n",
+			"/feeds/sub/child.yaml": "name: child\ngroup: web\nsteps:\n" +
+				"  - name: leaf\n    import: [leaf.yaml]\n",
+			"/feeds/sub/leaf.yaml": "name: leaf\ngroup: web\n",
+			"/common/steps.yaml": "steps:\n" +
+				"  - name: shared\n    import: [/feeds/sub/leaf.yaml]\n",
+		},
+	}
+	var httpServer *httptest.Server = httptest.NewServer(server)
+	defer httpServer.Close()
+
+	var feed IFeed = NewFeed("default")
+	if err := feed.LoadFrom(NewHttpFeedSource(httpServer.Client(), ""), httpServer.URL+"/feeds/main.yaml"); err != nil {
+		t.Fatalf("Unable to load feed: %v", err)
+	}
+	feedEx, errs := feed.Validate()
+	if len(errs) > 0 {
+		t.Fatalf("Unable to validate feed: %v", errs)
+	}
+	var expected []string = []string{"/feeds/main.yaml", "/feeds/sub/child.yaml", "/feeds/sub/leaf.yaml", "/common/steps.yaml", "/feeds/sub/leaf.yaml"}
+	if len(server.requests) != len(expected) {
+		t.Fatalf("Expected requests %v, got %v", expected, server.requests)
+	}
+	for index, path := range expected {
+		if server.requests[index] != path {
+			t.Fatalf("Expected requests %v, got %v", expected, server.requests)
+		}
+	}
+	if len(feedEx.Steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %v", len(feedEx.Steps))
+	}
+	if len(feedEx.Steps[0].Feeds) != 1 || feedEx.Steps[0].Feeds[0].Name != "child" {
+		t.Fatalf("Expected the child feed import, got %v", feedEx.Steps[0].Feeds)
+	}
+	if len(feedEx.Steps[1].Feeds) != 1 || feedEx.Steps[1].Feeds[0].Name != "leaf" {
+		t.Fatalf("Expected the included leaf feed import, got %v", feedEx.Steps[1].Feeds)
+	}
+}
+
+func TestHttpFeedSourceReusesCachedFeedOnNotModified(t *testing.T) {
+	setUpFeedsTest(t)
+	var server *feedsServer = &feedsServer{
+		documents: map[string]string{
+			"/main.yaml": "name: main\ngroup: web\n",
+		},
+	}
+	var httpServer *httptest.Server = httptest.NewServer(server)
+	defer httpServer.Close()
+
+	var source FeedSource = NewHttpFeedSource(httpServer.Client(), t.TempDir())
+	first, err := source.Read(httpServer.URL + "/main.yaml")
+	if err != nil {
+		t.Fatalf("Unable to read feed: %v", err)
+	}
+	if server.modified != 0 {
+		t.Fatalf("Expected a full download on the first read, got %v not modified answers", server.modified)
+	}
+	// Changes on the server are not visible, the ETag still matches
+	server.documents["/main.yaml"] = "name: changed\ngroup: web\n"
+	second, err := source.Read(httpServer.URL + "/main.yaml")
+	if err != nil {
+		t.Fatalf("Unable to read cached feed: %v", err)
+	}
+	if server.modified != 1 {
+		t.Fatalf("Expected a not modified answer on the second read, got %v", server.modified)
+	}
+	if string(second) != string(first) {
+		t.Fatalf("Expected cached content %q, got %q", string(first), string(second))
+	}
+}
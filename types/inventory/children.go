@@ -0,0 +1,148 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"strings"
+)
+
+// Nested host groups resolver
+type childrenResolver struct {
+	groups  []defaults.HostGroups
+	indexes map[string]int
+	parents map[int][]int
+	vars    map[int][]defaults.NameValue
+	hosts   map[int][]defaults.HostValue
+}
+
+// Verifies the children references of the host group and of its descendants, the path contains the groups from the
+// root to the host group
+func (resolver *childrenResolver) check(index int, path []int) error {
+	for _, pathIndex := range path {
+		if pathIndex == index {
+			var names []string = make([]string, 0)
+			for _, item := range append(path, index) {
+				names = append(names, resolver.groups[item].Name)
+			}
+			return errors.New(fmt.Sprintf("Cycle in host groups children: %s", strings.Join(names, " -> ")))
+		}
+	}
+	for _, child := range resolver.groups[index].Children {
+		childIndex, ok := resolver.indexes[strings.ToLower(strings.TrimSpace(child))]
+		if !ok {
+			return errors.New(fmt.Sprintf("Host group %s: unknown child group %s", resolver.groups[index].Name, child))
+		}
+		if err := resolver.check(childIndex, append(append([]int{}, path...), index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retrieves the host group variables, overriding the variables of its parent groups. The variables of a group with
+// several parents are merged in the parents declaration order, the later declared parents override the earlier ones
+func (resolver *childrenResolver) groupVars(index int) []defaults.NameValue {
+	if vars, ok := resolver.vars[index]; ok {
+		return vars
+	}
+	var vars []defaults.NameValue = make([]defaults.NameValue, 0)
+	for _, parent := range resolver.parents[index] {
+		vars = defaults.MergeVars(vars, resolver.groupVars(parent))
+	}
+	vars = defaults.MergeVars(vars, resolver.groups[index].Vars)
+	resolver.vars[index] = vars
+	return vars
+}
+
+// Retrieves the host group hosts followed by its descendants hosts, unique by name. Descendants hosts carry the
+// variables, connection settings and jump host of the host group they belong to
+func (resolver *childrenResolver) groupHosts(index int) []defaults.HostValue {
+	if hosts, ok := resolver.hosts[index]; ok {
+		return hosts
+	}
+	var group *defaults.HostGroups = &resolver.groups[index]
+	var hosts []defaults.HostValue = make([]defaults.HostValue, 0)
+	var names map[string]bool = make(map[string]bool)
+	for _, host := range group.Hosts {
+		if !names[host.Name] {
+			names[host.Name] = true
+			hosts = append(hosts, host)
+		}
+	}
+	for _, child := range group.Children {
+		var childIndex int = resolver.indexes[strings.ToLower(strings.TrimSpace(child))]
+		var childGroup *defaults.HostGroups = &resolver.groups[childIndex]
+		for _, host := range resolver.groupHosts(childIndex) {
+			if names[host.Name] {
+				continue
+			}
+			names[host.Name] = true
+			if containsHost(childGroup.Hosts, host.Name) {
+				host.Vars = defaults.MergeVars(resolver.groupVars(childIndex), host.Vars)
+				host.Connection = childGroup.Connection.Merge(host.Connection)
+				if host.JumpHost == nil {
+					host.JumpHost = childGroup.JumpHost
+				}
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	resolver.hosts[index] = hosts
+	return hosts
+}
+
+func containsHost(hosts []defaults.HostValue, name string) bool {
+	for _, host := range hosts {
+		if host.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolves the host groups children: each host group retrieves its hosts followed by the hosts of its children
+// groups, recursively and unique by name, and its variables overriding the ones of its parent groups.
+// When hosts share a name the first one wins: the group own hosts, then the children hosts in children order.
+// A child of several parents (diamond) inherits the variables of all of them, the later declared parents override the
+// earlier ones, so its hosts carry the same variables whichever parent group selects them.
+// Unknown children groups and cycles are reported as errors
+func ExpandChildren(groups []defaults.HostGroups) ([]defaults.HostGroups, error) {
+	var resolver *childrenResolver = &childrenResolver{
+		groups:  groups,
+		indexes: make(map[string]int),
+		parents: make(map[int][]int),
+		vars:    make(map[int][]defaults.NameValue),
+		hosts:   make(map[int][]defaults.HostValue),
+	}
+	var hasChildren bool = false
+	for index, group := range groups {
+		var name string = strings.ToLower(strings.TrimSpace(group.Name))
+		if _, ok := resolver.indexes[name]; !ok {
+			// Children refer the first host group with the given name
+			resolver.indexes[name] = index
+		}
+		hasChildren = hasChildren || len(group.Children) > 0
+	}
+	if !hasChildren {
+		return groups, nil
+	}
+	for index := range groups {
+		if err := resolver.check(index, []int{}); err != nil {
+			return nil, err
+		}
+	}
+	for index, group := range groups {
+		for _, child := range group.Children {
+			var childIndex int = resolver.indexes[strings.ToLower(strings.TrimSpace(child))]
+			resolver.parents[childIndex] = append(resolver.parents[childIndex], index)
+		}
+	}
+	var expanded []defaults.HostGroups = make([]defaults.HostGroups, 0)
+	for index, group := range groups {
+		group.Hosts = resolver.groupHosts(index)
+		group.Vars = resolver.groupVars(index)
+		expanded = append(expanded, group)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,147 @@
+package inventory
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"strings"
+	"testing"
+)
+
+func varsText(vars []defaults.NameValue) string {
+	var items []string = make([]string, 0)
+	for _, variable := range vars {
+		items = append(items, fmt.Sprintf("%s=%s", variable.Name, variable.Value))
+	}
+	return strings.Join(items, ",")
+}
+
+func hostsText(hosts []defaults.HostValue) string {
+	var items []string = make([]string, 0)
+	for _, host := range hosts {
+		items = append(items, fmt.Sprintf("%s(%s)", host.Name, host.IpAddress))
+	}
+	return strings.Join(items, ",")
+}
+
+func findGroup(t *testing.T, groups []defaults.HostGroups, name string) defaults.HostGroups {
+	t.Helper()
+	for _, group := range groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	t.Fatalf("Host group %s not found", name)
+	return defaults.HostGroups{}
+}
+
+func TestExpandChildrenWithoutChildren(t *testing.T) {
+	groups, err := ExpandChildren(testGroups())
+	if err != nil || groupNames(groups) != "web(2),db(2)" {
+		t.Fatalf("Unexpected host groups: %v %v", groups, err)
+	}
+}
+
+func TestExpandChildrenDedupesHostsByName(t *testing.T) {
+	groups, err := ExpandChildren([]defaults.HostGroups{
+		{
+			Name:     "web",
+			Children: []string{"canary", "eu"},
+			Hosts:    []defaults.HostValue{{Name: "web-01", IpAddress: "10.0.0.1"}},
+		},
+		{
+			Name: "canary",
+			Hosts: []defaults.HostValue{
+				{Name: "web-01", IpAddress: "10.9.9.1"},
+				{Name: "canary-01", IpAddress: "10.9.9.2"},
+			},
+		},
+		{
+			Name:     "eu",
+			Children: []string{"Canary"},
+			Hosts: []defaults.HostValue{
+				{Name: "eu-01", IpAddress: "10.1.0.1"},
+				{Name: "canary-01", IpAddress: "10.1.0.2"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to expand children: %v", err)
+	}
+	// The first host with a name wins: the group own hosts, then the children hosts in children order
+	for name, expected := range map[string]string{
+		"web":    "web-01(10.0.0.1),canary-01(10.9.9.2),eu-01(10.1.0.1)",
+		"canary": "web-01(10.9.9.1),canary-01(10.9.9.2)",
+		"eu":     "eu-01(10.1.0.1),canary-01(10.1.0.2),web-01(10.9.9.1)",
+	} {
+		if hosts := hostsText(findGroup(t, groups, name).Hosts); hosts != expected {
+			t.Errorf("Group %s: expected hosts %s, got %s", name, expected, hosts)
+		}
+	}
+}
+
+func TestExpandChildrenDiamondVars(t *testing.T) {
+	groups, err := ExpandChildren([]defaults.HostGroups{
+		{
+			Name:     "blue",
+			Children: []string{"app"},
+			Vars:     []defaults.NameValue{{Name: "env", Value: "blue"}, {Name: "color", Value: "blue"}},
+		},
+		{
+			Name:     "green",
+			Children: []string{"app"},
+			Vars:     []defaults.NameValue{{Name: "env", Value: "green"}, {Name: "tier", Value: "green"}},
+		},
+		{
+			Name:  "app",
+			Vars:  []defaults.NameValue{{Name: "tier", Value: "app"}},
+			Hosts: []defaults.HostValue{{Name: "app-01", Vars: []defaults.NameValue{{Name: "color", Value: "red"}}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to expand children: %v", err)
+	}
+	// The later declared parent overrides the earlier one, the child group and the host override the parents
+	if vars := varsText(findGroup(t, groups, "app").Vars); vars != "env=green,color=blue,tier=app" {
+		t.Fatalf("Unexpected child group vars: %s", vars)
+	}
+	for _, name := range []string{"blue", "green"} {
+		var group defaults.HostGroups = findGroup(t, groups, name)
+		if len(group.Hosts) != 1 {
+			t.Fatalf("Group %s: unexpected hosts %s", name, hostsText(group.Hosts))
+		}
+		if vars := varsText(group.Hosts[0].Vars); vars != "env=green,color=red,tier=app" {
+			t.Errorf("Group %s: unexpected host vars %s", name, vars)
+		}
+	}
+	if vars := varsText(findGroup(t, groups, "blue").Vars); vars != "env=blue,color=blue" {
+		t.Fatalf("Unexpected parent group vars: %s", vars)
+	}
+}
+
+func TestExpandChildrenErrors(t *testing.T) {
+	for _, test := range []struct {
+		groups   []defaults.HostGroups
+		expected string
+	}{
+		{
+			[]defaults.HostGroups{
+				{Name: "a", Children: []string{"b"}},
+				{Name: "b", Children: []string{"c"}},
+				{Name: "c", Children: []string{"a"}},
+			},
+			"Cycle in host groups children: a -> b -> c -> a",
+		},
+		{
+			[]defaults.HostGroups{{Name: "a", Children: []string{"A"}}},
+			"Cycle in host groups children: a -> a",
+		},
+		{
+			[]defaults.HostGroups{{Name: "a", Children: []string{"missing"}}},
+			"Host group a: unknown child group missing",
+		},
+	} {
+		if _, err := ExpandChildren(test.groups); err == nil || err.Error() != test.expected {
+			t.Errorf("Expected error %q, got %v", test.expected, err)
+		}
+	}
+}
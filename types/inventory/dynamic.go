@@ -0,0 +1,251 @@
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-tcp-common/log"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"strings"
+	"time"
+)
+
+var Logger log.Logger = nil
+
+// Prefix of hosts entries referring an executable inventory
+const EXEC_INVENTORY_PREFIX string = "exec:"
+
+// Prefix of hosts entries referring a Go plugin inventory
+const PLUGIN_INVENTORY_PREFIX string = "plugin:"
+
+// Symbol of the Go plugin inventories, a func() ([]defaults.HostGroups, error)
+const PLUGIN_INVENTORY_SYMBOL string = "GetInventory"
+
+// Argument given to executable inventories
+const EXEC_INVENTORY_ARGUMENT string = "--list"
+
+// Folder of the dynamic inventories cache, in the system folder
+const INVENTORY_CACHE_FOLDER string = "inventory"
+
+// Default time to live of the dynamic inventories cache
+const DEFAULT_INVENTORY_TTL time.Duration = 5 * time.Minute
+
+// Maximum duration of executable inventories
+var ExecTimeout time.Duration = 60 * time.Second
+
+// Dynamic inventory source
+type dynamicSource struct {
+	path     string
+	isPlugin bool
+}
+
+// Cached dynamic inventory
+type cacheEntry struct {
+	Source     string                `json:"source"`
+	Created    time.Time             `json:"created"`
+	HostGroups []defaults.HostGroups `json:"groups"`
+}
+
+// Discovers the dynamic inventory referred by a hosts entry: entries prefixed by exec: or plugin:, Go plugins
+// libraries (.so) and executable files, relative paths refer the config folder
+func dynamicSourceOf(entry string, configDir string) (*dynamicSource, bool) {
+	var source *dynamicSource = &dynamicSource{}
+	var path string = strings.TrimSpace(entry)
+	var explicit bool = false
+	if strings.HasPrefix(strings.ToLower(path), EXEC_INVENTORY_PREFIX) {
+		path = strings.TrimSpace(path[len(EXEC_INVENTORY_PREFIX):])
+		explicit = true
+	} else if strings.HasPrefix(strings.ToLower(path), PLUGIN_INVENTORY_PREFIX) {
+		path = strings.TrimSpace(path[len(PLUGIN_INVENTORY_PREFIX):])
+		source.isPlugin = true
+		explicit = true
+	}
+	if path == "" {
+		return nil, false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	source.path = path
+	if explicit {
+		return source, true
+	}
+	var ext string = strings.ToLower(filepath.Ext(path))
+	if ext == ".yml" || ext == ".yaml" || ext == ".json" || ext == ".xml" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return nil, false
+	}
+	if ext == ".so" {
+		source.isPlugin = true
+		return source, true
+	}
+	return source, info.Mode().Perm()&0111 != 0
+}
+
+// Verifies if a hosts entry refers a dynamic inventory, executable or Go plugin
+func IsDynamic(entry string, configDir string) bool {
+	_, ok := dynamicSourceOf(entry, configDir)
+	return ok
+}
+
+// Loads the host groups of a dynamic inventory, executable or Go plugin. Results are cached in the system folder for
+// the given time to live, 0 disables the cache. When the inventory fails, an expired cached result is used if present
+func LoadDynamic(entry string, configDir string, systemDir string, ttl time.Duration) ([]defaults.HostGroups, error) {
+	source, ok := dynamicSourceOf(entry, configDir)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Hosts entry %s doesn't refer an executable or plugin inventory", entry))
+	}
+	return loadCached(source.path, fmt.Sprintf("%v:%s", source.isPlugin, source.path), systemDir, ttl, func() ([]defaults.HostGroups, error) {
+		if source.isPlugin {
+			return loadPlugin(source.path)
+		}
+		return loadExecutable(source.path, configDir)
+	})
+}
+
+// Loads the host groups of an inventory source, identified in the cache by the given key. Results are cached in the
+// system folder for the given time to live, 0 disables the cache. When the source fails, an expired cached result is
+// used if present
+func loadCached(source string, key string, systemDir string, ttl time.Duration, load func() ([]defaults.HostGroups, error)) ([]defaults.HostGroups, error) {
+	var cacheFile string = ""
+	if systemDir != "" {
+		var sum [32]byte = sha256.Sum256([]byte(key))
+		cacheFile = filepath.Join(systemDir, INVENTORY_CACHE_FOLDER, hex.EncodeToString(sum[:16])+".json")
+	}
+	var cached *cacheEntry = nil
+	if cacheFile != "" && ttl > 0 {
+		cached = readCache(cacheFile)
+		if cached != nil && time.Since(cached.Created) < ttl {
+			if Logger != nil {
+				Logger.Debugf("Using cached inventory of %s, created at %s", source, cached.Created.Format(time.RFC3339))
+			}
+			return cached.HostGroups, nil
+		}
+	}
+	groups, err := load()
+	if err != nil {
+		if cached != nil {
+			if Logger != nil {
+				Logger.Warnf("Inventory %s failed, using the cached inventory created at %s -> %s", source, cached.Created.Format(time.RFC3339), err.Error())
+			}
+			return cached.HostGroups, nil
+		}
+		return nil, err
+	}
+	if cacheFile != "" && ttl > 0 {
+		if errW := writeCache(cacheFile, cacheEntry{
+			Source:     source,
+			Created:    time.Now(),
+			HostGroups: groups,
+		}); errW != nil && Logger != nil {
+			Logger.Warnf("Unable to cache inventory of %s -> %s", source, errW.Error())
+		}
+	}
+	return groups, nil
+}
+
+// Runs an executable inventory and parses its JSON output, as hosts file content or list of host groups
+func loadExecutable(path string, workDir string) ([]defaults.HostGroups, error) {
+	var ctx context.Context = context.Background()
+	if ExecTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ExecTimeout)
+		defer cancel()
+	}
+	var cmd *exec.Cmd = exec.CommandContext(ctx, path, EXEC_INVENTORY_ARGUMENT)
+	cmd.Dir = workDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Inventory executable %s failed -> %s %s", path, err.Error(), strings.TrimSpace(stderr.String())))
+	}
+	groups, err := parseInventory(output)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Inventory executable %s output -> %s", path, err.Error()))
+	}
+	return groups, nil
+}
+
+// Parses an inventory JSON content, as hosts file content ({"groups": [...]}) or list of host groups
+func parseInventory(data []byte) ([]defaults.HostGroups, error) {
+	var text string = strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "[") {
+		var groups []defaults.HostGroups = make([]defaults.HostGroups, 0)
+		if err := json.Unmarshal([]byte(text), &groups); err != nil {
+			return nil, err
+		}
+		return groups, nil
+	}
+	var config defaults.HostGroupsConfig = defaults.HostGroupsConfig{}
+	if err := json.Unmarshal([]byte(text), &config); err != nil {
+		return nil, err
+	}
+	if config.HostGroups == nil {
+		return nil, errors.New("No host groups found")
+	}
+	return config.HostGroups, nil
+}
+
+// Loads the host groups of a Go plugin inventory
+func loadPlugin(path string) ([]defaults.HostGroups, error) {
+	library, err := plugin.Open(path)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to open inventory plugin %s -> %s", path, err.Error()))
+	}
+	sym, err := library.Lookup(PLUGIN_INVENTORY_SYMBOL)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Inventory plugin %s: symbol %s not found -> %s", path, PLUGIN_INVENTORY_SYMBOL, err.Error()))
+	}
+	getInventory, ok := sym.(func() ([]defaults.HostGroups, error))
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Inventory plugin %s: symbol %s is %T, expected func() ([]defaults.HostGroups, error)", path, PLUGIN_INVENTORY_SYMBOL, sym))
+	}
+	groups, err := getInventory()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Inventory plugin %s failed -> %s", path, err.Error()))
+	}
+	return groups, nil
+}
+
+func readCache(file string) *cacheEntry {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var cached cacheEntry = cacheEntry{}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		if Logger != nil {
+			Logger.Warnf("Ignoring invalid inventory cache file %s -> %s", file, err.Error())
+		}
+		return nil
+	}
+	return &cached
+}
+
+// Writes the cache file, readable only by the current user as inventories may contain credentials
+func writeCache(file string, cached cacheEntry) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	var temp string = file + ".tmp"
+	if err := ioutil.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, file)
+}
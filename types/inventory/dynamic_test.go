@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"errors"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Writes an inventory script printing the given output when called with the list argument
+func writeInventoryScript(t *testing.T, folder string, name string, output string, mode os.FileMode) string {
+	t.Helper()
+	var file string = filepath.Join(folder, name)
+	var script string = "#!/bin/sh\n[ \"$1\" = \"" + EXEC_INVENTORY_ARGUMENT + "\" ] || exit 2\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if err := ioutil.WriteFile(file, []byte(script), mode); err != nil {
+		t.Fatalf("Unable to write inventory script: %v", err)
+	}
+	return file
+}
+
+func TestParseInventory(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		expected string
+	}{
+		{`[{"name":"web","hosts":[{"name":"web-01"},{"name":"web-02"}]}]`, "web(2)"},
+		{"  \n[]", ""},
+		{`{"groups":[{"name":"web","hosts":[{"name":"web-01"}]},{"name":"db","hosts":[]}]}`, "web(1),db(0)"},
+		{`{"groups":[]}`, ""},
+	} {
+		groups, err := parseInventory([]byte(test.data))
+		if err != nil {
+			t.Fatalf("Unable to parse %s: %v", test.data, err)
+		}
+		if names := groupNames(groups); names != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.data, test.expected, names)
+		}
+	}
+	for _, data := range []string{
+		``,
+		`not json`,
+		`[{"name":"web"`,
+		`{"hosts":[]}`,
+		`"web"`,
+	} {
+		if groups, err := parseInventory([]byte(data)); err == nil {
+			t.Errorf("%q: expected a parse error, got %v", data, groups)
+		}
+	}
+}
+
+func TestDynamicSourceOf(t *testing.T) {
+	var configDir string = t.TempDir()
+	writeInventoryScript(t, configDir, "cmdb.sh", "[]", 0755)
+	writeInventoryScript(t, configDir, "static", "[]", 0644)
+	writeInventoryScript(t, configDir, "hosts.yml", "[]", 0755)
+	writeInventoryScript(t, configDir, "inventory.so", "", 0644)
+	if err := os.Mkdir(filepath.Join(configDir, "folder"), 0755); err != nil {
+		t.Fatalf("Unable to create folder: %v", err)
+	}
+	for _, test := range []struct {
+		entry    string
+		path     string
+		isPlugin bool
+	}{
+		// Executable bit detection
+		{"cmdb.sh", filepath.Join(configDir, "cmdb.sh"), false},
+		{filepath.Join(configDir, "cmdb.sh"), filepath.Join(configDir, "cmdb.sh"), false},
+		{"inventory.so", filepath.Join(configDir, "inventory.so"), true},
+		// Explicit prefixes don't verify the file
+		{"exec:static", filepath.Join(configDir, "static"), false},
+		{"EXEC: scripts/missing.py", filepath.Join(configDir, "scripts/missing.py"), false},
+		{"plugin:/opt/inventory/cmdb.so", "/opt/inventory/cmdb.so", true},
+		{"Plugin: lib/cmdb", filepath.Join(configDir, "lib/cmdb"), true},
+	} {
+		source, ok := dynamicSourceOf(test.entry, configDir)
+		if !ok {
+			t.Errorf("%s: expected a dynamic inventory", test.entry)
+			continue
+		}
+		if source.path != test.path || source.isPlugin != test.isPlugin {
+			t.Errorf("%s: expected %s (plugin: %v), got %s (plugin: %v)", test.entry, test.path, test.isPlugin, source.path, source.isPlugin)
+		}
+	}
+	for _, entry := range []string{
+		"static",
+		"hosts.yml",
+		"missing.sh",
+		"folder",
+		"exec:",
+		"plugin:  ",
+		"",
+	} {
+		if IsDynamic(entry, configDir) {
+			t.Errorf("%q: unexpected dynamic inventory", entry)
+		}
+	}
+}
+
+func TestLoadDynamicExecutable(t *testing.T) {
+	var configDir string = t.TempDir()
+	writeInventoryScript(t, configDir, "cmdb.sh", `{"groups":[{"name":"web","hosts":[{"name":"web-01"}]}]}`, 0755)
+	groups, err := LoadDynamic("cmdb.sh", configDir, t.TempDir(), 0)
+	if err != nil || groupNames(groups) != "web(1)" {
+		t.Fatalf("Unexpected host groups: %v %v", groups, err)
+	}
+	writeInventoryScript(t, configDir, "broken.sh", "not json", 0755)
+	if _, err = LoadDynamic("broken.sh", configDir, "", 0); err == nil || !strings.Contains(err.Error(), "broken.sh") {
+		t.Fatalf("Expected an output error, got %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(configDir, "failing.sh"), []byte("#!/bin/sh\necho 'cmdb unavailable' >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("Unable to write inventory script: %v", err)
+	}
+	if _, err = LoadDynamic("failing.sh", configDir, "", 0); err == nil || !strings.Contains(err.Error(), "cmdb unavailable") {
+		t.Fatalf("Expected the executable error, got %v", err)
+	}
+	if _, err = LoadDynamic("hosts.yml", configDir, "", 0); err == nil {
+		t.Fatal("Expected an error for a static hosts file")
+	}
+}
+
+func TestLoadCached(t *testing.T) {
+	var systemDir string = t.TempDir()
+	var loads int = 0
+	var loadErr error = nil
+	var load func() ([]defaults.HostGroups, error) = func() ([]defaults.HostGroups, error) {
+		loads++
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return []defaults.HostGroups{{Name: "web", Hosts: make([]defaults.HostValue, loads)}}, nil
+	}
+	// Fresh cache entries are used
+	for index := 0; index < 2; index++ {
+		if groups, err := loadCached("cmdb", "key", systemDir, time.Hour, load); err != nil || groupNames(groups) != "web(1)" {
+			t.Fatalf("Unexpected host groups: %v %v", groups, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("Expected a single load, got %v", loads)
+	}
+	// Expired cache entries are reloaded
+	if groups, err := loadCached("cmdb", "key", systemDir, time.Nanosecond, load); err != nil || groupNames(groups) != "web(2)" {
+		t.Fatalf("Unexpected host groups: %v %v", groups, err)
+	}
+	// Expired cache entries are used when the source fails
+	loadErr = errors.New("cmdb unavailable")
+	time.Sleep(time.Millisecond)
+	if groups, err := loadCached("cmdb", "key", systemDir, time.Nanosecond, load); err != nil || groupNames(groups) != "web(2)" {
+		t.Fatalf("Expected the expired cached host groups, got %v %v", groups, err)
+	}
+	if loads != 3 {
+		t.Fatalf("Expected the source loaded when the cache expired, got %v loads", loads)
+	}
+	// Without a cache entry of the key the error is retrieved
+	if _, err := loadCached("cmdb", "other", systemDir, time.Hour, load); err != loadErr {
+		t.Fatalf("Expected the source error, got %v", err)
+	}
+	// The cache is disabled with no time to live
+	if _, err := loadCached("cmdb", "key", systemDir, 0, load); err != loadErr {
+		t.Fatalf("Expected the source error without cache, got %v", err)
+	}
+	files, err := ioutil.ReadDir(filepath.Join(systemDir, INVENTORY_CACHE_FOLDER))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected a single cache file, got %v %v", files, err)
+	}
+	if files[0].Mode().Perm() != 0600 {
+		t.Fatalf("Unexpected cache file permissions: %v", files[0].Mode().Perm())
+	}
+}
+
+func TestLoadCachedIgnoresInvalidCache(t *testing.T) {
+	var systemDir string = t.TempDir()
+	var load func() ([]defaults.HostGroups, error) = func() ([]defaults.HostGroups, error) {
+		return []defaults.HostGroups{{Name: "web"}}, nil
+	}
+	if _, err := loadCached("cmdb", "key", systemDir, time.Hour, load); err != nil {
+		t.Fatalf("Unable to load host groups: %v", err)
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(systemDir, INVENTORY_CACHE_FOLDER))
+	if len(files) != 1 {
+		t.Fatalf("Expected a single cache file, got %v", files)
+	}
+	var cacheFile string = filepath.Join(systemDir, INVENTORY_CACHE_FOLDER, files[0].Name())
+	if err := ioutil.WriteFile(cacheFile, []byte("{"), 0600); err != nil {
+		t.Fatalf("Unable to corrupt cache file: %v", err)
+	}
+	if _, err := loadCached("cmdb", "key", systemDir, time.Hour, func() ([]defaults.HostGroups, error) {
+		return nil, errors.New("cmdb unavailable")
+	}); err == nil {
+		t.Fatal("Expected the source error with an invalid cache")
+	}
+}
@@ -0,0 +1,273 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Pattern matching all the hosts of all the groups
+const ALL_HOSTS_PATTERN string = "all"
+
+// Pattern term, matching group names and hosts names, host names or ip addresses
+type patternTerm struct {
+	text     string
+	regex    *regexp.Regexp
+	glob     string
+	operator byte
+}
+
+func (term *patternTerm) match(name string) bool {
+	if name == "" {
+		return false
+	}
+	if term.regex != nil {
+		return term.regex.MatchString(name)
+	}
+	var lower string = strings.ToLower(name)
+	if lower == term.glob {
+		return true
+	}
+	ok, err := path.Match(term.glob, lower)
+	return err == nil && ok
+}
+
+// Splits a hosts pattern in terms separated by comma or colon. Regular expressions quoted by " or ' (e.g.:
+// ~"web-0{1,3}") can contain separators, the unquoted ones end at the first separator
+func splitPattern(text string) ([]string, error) {
+	var items []string = make([]string, 0)
+	var item strings.Builder
+	var quote byte = 0
+	for index := 0; index < len(text); index++ {
+		var c byte = text[index]
+		if quote != 0 {
+			item.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == ',' || c == ':' {
+			items = append(items, item.String())
+			item.Reset()
+			continue
+		}
+		if c == '"' || c == '\'' {
+			// Quotes open only right after the ~ of a regular expression term
+			var prefix string = strings.TrimSpace(item.String())
+			if prefix != "" && (prefix[0] == '&' || prefix[0] == '!') {
+				prefix = strings.TrimSpace(prefix[1:])
+			}
+			if prefix == "~" {
+				quote = c
+			}
+		}
+		item.WriteByte(c)
+	}
+	if quote != 0 {
+		return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: unterminated quote %c", text, quote))
+	}
+	return append(items, item.String()), nil
+}
+
+// Parses a hosts pattern: a list of terms separated by comma or colon (e.g.: web:db), where terms are group or host
+// glob patterns (e.g.: web-*), or regular expressions prefixed by ~ (e.g.: ~web-0[1-3]). Regular expressions
+// containing commas or colons must be quoted (e.g.: ~"web-0{1,3}"), as unquoted terms end at the first separator.
+// Terms prefixed by & restrict the selection to the matching hosts, terms prefixed by ! exclude the matching hosts
+func parsePattern(text string) ([]patternTerm, error) {
+	var terms []patternTerm = make([]patternTerm, 0)
+	items, err := splitPattern(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var term patternTerm = patternTerm{
+			text: item,
+		}
+		if item[0] == '&' || item[0] == '!' {
+			term.operator = item[0]
+			item = strings.TrimSpace(item[1:])
+		}
+		if item == "" {
+			return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: empty term %s", text, term.text))
+		}
+		if strings.HasPrefix(item, "~") {
+			var expression string = strings.TrimSpace(item[1:])
+			if expression != "" && (expression[0] == '"' || expression[0] == '\'') {
+				if len(expression) < 2 || expression[len(expression)-1] != expression[0] {
+					return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: term %s -> text after the quoted regular expression", text, term.text))
+				}
+				expression = expression[1 : len(expression)-1]
+			} else {
+				expression = item[1:]
+			}
+			regex, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: term %s -> %s", text, term.text, err.Error()))
+			}
+			term.regex = regex
+		} else {
+			term.glob = strings.ToLower(item)
+			if term.glob == ALL_HOSTS_PATTERN {
+				term.glob = "*"
+			}
+			if _, err := path.Match(term.glob, ""); err != nil {
+				return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: term %s -> %s", text, term.text, err.Error()))
+			}
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, errors.New(fmt.Sprintf("Invalid hosts pattern %s: no terms", text))
+	}
+	return terms, nil
+}
+
+// Host of a host group
+type groupHost struct {
+	group *defaults.HostGroups
+	host  defaults.HostValue
+}
+
+// Retrieves the hosts matching a term: all the hosts of the groups with matching name or, when no group name
+// matches, the hosts with matching name, host name or ip address
+func (term *patternTerm) hosts(groups []defaults.HostGroups) []groupHost {
+	var out []groupHost = make([]groupHost, 0)
+	for index := range groups {
+		var group *defaults.HostGroups = &groups[index]
+		if term.match(group.Name) {
+			for _, host := range group.Hosts {
+				out = append(out, groupHost{group, host})
+			}
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	for index := range groups {
+		var group *defaults.HostGroups = &groups[index]
+		for _, host := range group.Hosts {
+			if term.match(host.Name) || term.match(host.HostName) || term.match(host.IpAddress) {
+				out = append(out, groupHost{group, host})
+			}
+		}
+	}
+	return out
+}
+
+// Retrieves the hosts matching the pattern in groups and hosts order, hosts are unique by name
+func matchHosts(groups []defaults.HostGroups, pattern string) ([]groupHost, error) {
+	terms, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var selected []groupHost = make([]groupHost, 0)
+	var names map[string]bool = make(map[string]bool)
+	var hasUnion bool = false
+	for _, term := range terms {
+		if term.operator == 0 {
+			hasUnion = true
+			for _, item := range term.hosts(groups) {
+				if !names[item.host.Name] {
+					names[item.host.Name] = true
+					selected = append(selected, item)
+				}
+			}
+		}
+	}
+	if !hasUnion {
+		// Restrictions and exclusions only apply to all the hosts
+		return matchHosts(groups, ALL_HOSTS_PATTERN+","+pattern)
+	}
+	for _, term := range terms {
+		if term.operator == 0 {
+			continue
+		}
+		var matching map[string]bool = make(map[string]bool)
+		for _, item := range term.hosts(groups) {
+			matching[item.host.Name] = true
+		}
+		var filtered []groupHost = make([]groupHost, 0)
+		for _, item := range selected {
+			if matching[item.host.Name] == (term.operator == '&') {
+				filtered = append(filtered, item)
+			}
+		}
+		selected = filtered
+	}
+	return selected, nil
+}
+
+// Retrieves the hosts matching the pattern, further restricted to the hosts matching the limit pattern, if any.
+// When all the hosts belong to a single group and the limit doesn't exclude any of them, the group is retrieved,
+// otherwise a group named as the pattern is retrieved, with hosts referring the host group they belong to, their
+// jump host defaulting to the host group one
+func SelectHosts(groups []defaults.HostGroups, pattern string, limit string) (*defaults.HostGroups, error) {
+	selected, err := matchHosts(groups, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var limited bool = false
+	if strings.TrimSpace(limit) != "" {
+		allowed, err := matchHosts(groups, limit)
+		if err != nil {
+			return nil, err
+		}
+		var names map[string]bool = make(map[string]bool)
+		for _, item := range allowed {
+			names[item.host.Name] = true
+		}
+		var filtered []groupHost = make([]groupHost, 0)
+		for _, item := range selected {
+			if names[item.host.Name] {
+				filtered = append(filtered, item)
+			}
+		}
+		limited = len(filtered) < len(selected)
+		selected = filtered
+	}
+	if len(selected) == 0 && limited {
+		return nil, errors.New(fmt.Sprintf("No host matches the hosts pattern %s and the limit %s", pattern, limit))
+	}
+	if len(selected) == 0 {
+		return nil, errors.New(fmt.Sprintf("No host matches the hosts pattern %s", pattern))
+	}
+	var single *defaults.HostGroups = selected[0].group
+	for _, item := range selected {
+		if item.group != single {
+			single = nil
+			break
+		}
+	}
+	if single != nil && !limited && len(selected) == len(single.Hosts) {
+		return single, nil
+	}
+	var out defaults.HostGroups = defaults.HostGroups{
+		Name:  pattern,
+		Hosts: make([]defaults.HostValue, 0),
+	}
+	for _, item := range selected {
+		var host defaults.HostValue = item.host
+		host.Group = item.group.Name
+		if host.JumpHost == nil {
+			host.JumpHost = item.group.JumpHost
+		}
+		out.Hosts = append(out.Hosts, host)
+	}
+	return &out, nil
+}
+
+// Retrieves the session key of a host of the given host group, or of the host group the host refers
+func SessionKey(group *defaults.HostGroups, host defaults.HostValue) string {
+	if host.Group != "" {
+		return fmt.Sprintf("%s-%s", host.Group, host.Name)
+	}
+	return fmt.Sprintf("%s-%s", group.Name, host.Name)
+}
@@ -0,0 +1,138 @@
+package inventory
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"strings"
+	"testing"
+)
+
+func testGroups() []defaults.HostGroups {
+	return []defaults.HostGroups{
+		{
+			Name: "web",
+			Hosts: []defaults.HostValue{
+				{Name: "web-01", IpAddress: "10.0.0.1"},
+				{Name: "web-02", IpAddress: "10.0.0.2"},
+			},
+		},
+		{
+			Name: "db",
+			Hosts: []defaults.HostValue{
+				{Name: "db-01", IpAddress: "10.0.1.1"},
+				// Host named as another group
+				{Name: "web", IpAddress: "10.0.1.2"},
+			},
+		},
+	}
+}
+
+func TestSelectHosts(t *testing.T) {
+	for _, test := range []struct {
+		pattern  string
+		limit    string
+		expected []string
+	}{
+		{"web", "", []string{"web-01", "web-02"}},
+		{"WEB", "", []string{"web-01", "web-02"}},
+		{"db", "", []string{"db-01", "web"}},
+		{"web-01", "", []string{"web-01"}},
+		{"10.0.1.*", "", []string{"db-01", "web"}},
+		{"~^web-0[2-9]$", "", []string{"web-02"}},
+		{"web:db", "", []string{"web-01", "web-02", "db-01", "web"}},
+		{"all:!db", "", []string{"web-01", "web-02"}},
+		{"!web-01", "", []string{"web-02", "db-01", "web"}},
+		{"db:&web", "", []string{}},
+		{"web", "web-02", []string{"web-02"}},
+		// Quoted regular expressions can contain separators
+		{`~"^web-[0-9]{1,2}$"`, "", []string{"web-01", "web-02"}},
+		{`db:~"^10[.]0[.]0[.][0-9]{1,3}$":!web-01`, "", []string{"db-01", "web", "web-02"}},
+	} {
+		group, err := SelectHosts(testGroups(), test.pattern, test.limit)
+		if len(test.expected) == 0 {
+			if err == nil {
+				t.Errorf("Pattern %s: expected no host, got %v", test.pattern, group.Hosts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Pattern %s: unable to select hosts: %v", test.pattern, err)
+			continue
+		}
+		var names []string = make([]string, 0)
+		for _, host := range group.Hosts {
+			names = append(names, host.Name)
+		}
+		if len(names) != len(test.expected) {
+			t.Errorf("Pattern %s limit %s: expected %v, got %v", test.pattern, test.limit, test.expected, names)
+			continue
+		}
+		for index := range names {
+			if names[index] != test.expected[index] {
+				t.Errorf("Pattern %s limit %s: expected %v, got %v", test.pattern, test.limit, test.expected, names)
+				break
+			}
+		}
+	}
+}
+
+func TestSelectHostsKeepsHostGroups(t *testing.T) {
+	group, err := SelectHosts(testGroups(), "web-02:db-01", "")
+	if err != nil {
+		t.Fatalf("Unable to select hosts: %v", err)
+	}
+	if group.Name != "web-02:db-01" || group.Hosts[0].Group != "web" || group.Hosts[1].Group != "db" {
+		t.Fatalf("Unexpected selected group: %v", group)
+	}
+	if key := SessionKey(group, group.Hosts[1]); key != "db-db-01" {
+		t.Fatalf("Unexpected session key: %s", key)
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	for _, test := range []struct {
+		pattern  string
+		expected []string
+	}{
+		{"web:db, cache", []string{"web", "db", "cache"}},
+		{"web::db,", []string{"web", "db"}},
+		// Unquoted regular expressions end at the first separator
+		{"~web-0{1,3}", []string{"~web-0{1", "3}"}},
+		{`~"web-0{1,3}":db`, []string{`~"web-0{1,3}"`, "db"}},
+		{`&~ 'host:[0-9]+' , ! ~"a,b"`, []string{`&~ 'host:[0-9]+'`, `! ~"a,b"`}},
+		// Quotes not following ~ are part of the term
+		{`we"b,db"`, []string{`we"b`, `db"`}},
+	} {
+		terms, err := parsePattern(test.pattern)
+		if err != nil {
+			t.Errorf("Pattern %s: unable to parse: %v", test.pattern, err)
+			continue
+		}
+		var texts []string = make([]string, 0)
+		for _, term := range terms {
+			texts = append(texts, term.text)
+		}
+		if strings.Join(texts, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("Pattern %s: expected terms %q, got %q", test.pattern, test.expected, texts)
+		}
+	}
+	terms, err := parsePattern(`~"^web-[0-9]{1,3}$"`)
+	if err != nil || len(terms) != 1 || terms[0].regex == nil || terms[0].regex.String() != "^web-[0-9]{1,3}$" {
+		t.Fatalf("Unexpected quoted regular expression: %v %v", terms, err)
+	}
+	if !terms[0].match("web-002") || terms[0].match("web-") {
+		t.Fatalf("Unexpected quoted regular expression matches: %s", terms[0].regex)
+	}
+	for _, pattern := range []string{
+		`~"web-0{1,3}`,
+		`~'web'-01`,
+		`~"`,
+		"~web-[",
+		",:",
+		"web:!",
+		"web-[",
+	} {
+		if terms, err := parsePattern(pattern); err == nil {
+			t.Errorf("Pattern %s: expected a parse error, got %v", pattern, terms)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/utils"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeout of the inventory providers requests
+const DEFAULT_PROVIDER_TIMEOUT time.Duration = 30 * time.Second
+
+// Default key prefix of the Consul inventory provider
+const DEFAULT_CONSUL_PREFIX string = "go-deploy/inventory"
+
+// Inventory provider, retrieves host groups from an external source
+type Provider interface {
+	// Retrieves the provider host groups
+	GetHostGroups() ([]defaults.HostGroups, error)
+}
+
+// Creates an inventory provider from its configuration, relative file paths refer the given config folder
+type ProviderFactory func(config module.InventoryProviderConfig, configDir string) (Provider, error)
+
+var providersMutex sync.RWMutex
+var providerFactories map[module.InventoryProviderTypeValue]ProviderFactory = map[module.InventoryProviderTypeValue]ProviderFactory{
+	module.INVENTORY_PROVIDER_REST:   newRestProvider,
+	module.INVENTORY_PROVIDER_CONSUL: newConsulProvider,
+}
+
+// Registers an inventory provider type, replacing any provider registered with the same type
+func RegisterProvider(providerType module.InventoryProviderTypeValue, factory ProviderFactory) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	providerFactories[module.InventoryProviderTypeValue(strings.ToLower(string(providerType)))] = factory
+}
+
+// Creates an inventory provider of the configured type
+func NewProvider(config module.InventoryProviderConfig, configDir string) (Provider, error) {
+	providersMutex.RLock()
+	factory, ok := providerFactories[module.InventoryProviderTypeValue(strings.ToLower(strings.TrimSpace(string(config.Type))))]
+	providersMutex.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Inventory provider %s: unknown type %s", providerName(config), config.Type))
+	}
+	return factory(config, configDir)
+}
+
+// Loads the host groups of the configured inventory providers, in configuration order. Results are cached in the
+// system folder for the given time to live, 0 disables the cache. When a provider fails, an expired cached result is
+// used if present
+func LoadProviders(config *module.InventoryConfig, configDir string, systemDir string, ttl time.Duration) ([]defaults.HostGroups, error) {
+	var groups []defaults.HostGroups = make([]defaults.HostGroups, 0)
+	if config == nil {
+		return groups, nil
+	}
+	for _, providerConfig := range config.Providers {
+		provider, err := NewProvider(providerConfig, configDir)
+		if err != nil {
+			return nil, err
+		}
+		var name string = providerName(providerConfig)
+		var key string = fmt.Sprintf("provider:%s:%s:%s", strings.ToLower(string(providerConfig.Type)), providerConfig.Url, providerConfig.Prefix)
+		providerGroups, err := loadCached(name, key, systemDir, ttl, provider.GetHostGroups)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Inventory provider %s failed -> %s", name, err.Error()))
+		}
+		if Logger != nil {
+			Logger.Debugf("Inventory provider %s retrieved %v host groups", name, len(providerGroups))
+		}
+		groups = append(groups, providerGroups...)
+	}
+	return groups, nil
+}
+
+func providerName(config module.InventoryProviderConfig) string {
+	if config.Name != "" {
+		return config.Name
+	}
+	return config.Url
+}
+
+// Creates the HTTP client of an inventory provider, timeout is a Go duration string (e.g.: 10s) or a number of seconds
+func newProviderClient(config module.InventoryProviderConfig, configDir string) (*http.Client, error) {
+	var timeout time.Duration = DEFAULT_PROVIDER_TIMEOUT
+	var text string = strings.TrimSpace(config.Timeout)
+	if text != "" {
+		if seconds, err := strconv.ParseFloat(text, 64); err == nil && seconds > 0 {
+			timeout = time.Duration(seconds * float64(time.Second))
+		} else if duration, err := time.ParseDuration(text); err == nil && duration > 0 {
+			timeout = duration
+		} else {
+			return nil, errors.New(fmt.Sprintf("Inventory provider %s: invalid timeout %s, expected a duration (e.g.: 10s) or a number of seconds", providerName(config), text))
+		}
+	}
+	client, err := utils.NewHttpClient(utils.ResolvePath(config.CaCert, configDir), utils.ResolvePath(config.Certificate, configDir),
+		utils.ResolvePath(config.KeyFile, configDir), config.Insecure, timeout)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Inventory provider %s -> %s", providerName(config), err.Error()))
+	}
+	return client, nil
+}
+
+// Runs a GET request, retrieving the response body and status code
+func httpGet(client *http.Client, address string, headers map[string]string) ([]byte, int, error) {
+	request, err := http.NewRequest(http.MethodGet, address, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	request.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, response.StatusCode, err
+	}
+	return body, response.StatusCode, nil
+}
+
+func statusError(address string, status int, body []byte) error {
+	var text string = strings.TrimSpace(string(body))
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	return errors.New(fmt.Sprintf("GET %s answered %v %s %s", address, status, http.StatusText(status), text))
+}
+
+// REST inventory provider, e.g.: a CMDB endpoint answering with hosts file content or a list of host groups, in JSON
+// format. The token, if any, is sent as bearer authorization
+type restProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (provider *restProvider) GetHostGroups() ([]defaults.HostGroups, error) {
+	var headers map[string]string = make(map[string]string)
+	if provider.token != "" {
+		headers["Authorization"] = "Bearer " + provider.token
+	}
+	body, status, err := httpGet(provider.client, provider.url, headers)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(provider.url, status, body)
+	}
+	groups, err := parseInventory(body)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("GET %s response -> %s", provider.url, err.Error()))
+	}
+	return groups, nil
+}
+
+func newRestProvider(config module.InventoryProviderConfig, configDir string) (Provider, error) {
+	if strings.TrimSpace(config.Url) == "" {
+		return nil, errors.New(fmt.Sprintf("Inventory provider %s: missing url", providerName(config)))
+	}
+	client, err := newProviderClient(config, configDir)
+	if err != nil {
+		return nil, err
+	}
+	return &restProvider{
+		url:    strings.TrimSpace(config.Url),
+		token:  config.Token,
+		client: client,
+	}, nil
+}
+
+// Consul-like KV inventory provider, each key under the prefix contains a host group in JSON format, named as the last
+// key segment when the name is missing, or a list of host groups. The token, if any, is sent as Consul token
+type consulProvider struct {
+	url    string
+	prefix string
+	token  string
+	client *http.Client
+}
+
+// Consul KV entry, values are base64 encoded
+type consulEntry struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"`
+}
+
+func (provider *consulProvider) GetHostGroups() ([]defaults.HostGroups, error) {
+	var address string = provider.url + "/v1/kv/" + provider.prefix + "?recurse=true"
+	var headers map[string]string = make(map[string]string)
+	if provider.token != "" {
+		headers["X-Consul-Token"] = provider.token
+	}
+	body, status, err := httpGet(provider.client, address, headers)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		// No keys under the prefix
+		return make([]defaults.HostGroups, 0), nil
+	}
+	if status != http.StatusOK {
+		return nil, statusError(address, status, body)
+	}
+	var entries []consulEntry = make([]consulEntry, 0)
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, errors.New(fmt.Sprintf("GET %s response -> %s", address, err.Error()))
+	}
+	var groups []defaults.HostGroups = make([]defaults.HostGroups, 0)
+	for _, entry := range entries {
+		var value string = strings.TrimSpace(string(entry.Value))
+		if strings.HasSuffix(entry.Key, "/") || value == "" {
+			continue
+		}
+		if strings.HasPrefix(value, "[") {
+			list, err := parseInventory([]byte(value))
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("Key %s -> %s", entry.Key, err.Error()))
+			}
+			groups = append(groups, list...)
+			continue
+		}
+		var group defaults.HostGroups = defaults.HostGroups{}
+		if err := json.Unmarshal([]byte(value), &group); err != nil {
+			return nil, errors.New(fmt.Sprintf("Key %s -> %s", entry.Key, err.Error()))
+		}
+		if group.Name == "" {
+			group.Name = entry.Key[strings.LastIndex(entry.Key, "/")+1:]
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func newConsulProvider(config module.InventoryProviderConfig, configDir string) (Provider, error) {
+	var address string = strings.TrimRight(strings.TrimSpace(config.Url), "/")
+	if address == "" {
+		return nil, errors.New(fmt.Sprintf("Inventory provider %s: missing url", providerName(config)))
+	}
+	var prefix string = strings.Trim(strings.TrimSpace(config.Prefix), "/")
+	if prefix == "" {
+		prefix = DEFAULT_CONSUL_PREFIX
+	}
+	var segments []string = strings.Split(prefix, "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+	client, err := newProviderClient(config, configDir)
+	if err != nil {
+		return nil, err
+	}
+	return &consulProvider{
+		url:    address,
+		prefix: strings.Join(segments, "/"),
+		token:  config.Token,
+		client: client,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package inventory
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Inventory server answering with a fixed status and body, it records the requests headers
+type inventoryServer struct {
+	sync.Mutex
+	status  int
+	body    string
+	paths   []string
+	headers []http.Header
+}
+
+func (server *inventoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+	server.paths = append(server.paths, r.URL.RequestURI())
+	server.headers = append(server.headers, r.Header)
+	w.WriteHeader(server.status)
+	w.Write([]byte(server.body))
+}
+
+func (server *inventoryServer) answer(status int, body string) {
+	server.Lock()
+	defer server.Unlock()
+	server.status = status
+	server.body = body
+}
+
+func newInventoryServer(t *testing.T, status int, body string) (*inventoryServer, string) {
+	var server *inventoryServer = &inventoryServer{status: status, body: body}
+	var httpServer *httptest.Server = httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return server, httpServer.URL
+}
+
+func consulValue(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func groupNames(groups []defaults.HostGroups) string {
+	var names []string = make([]string, 0)
+	for _, group := range groups {
+		names = append(names, fmt.Sprintf("%s(%v)", group.Name, len(group.Hosts)))
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRestProvider(t *testing.T) {
+	server, address := newInventoryServer(t, http.StatusOK, `{"groups":[{"name":"web","hosts":[{"name":"web-01"},{"name":"web-02"}]}]}`)
+	provider, err := NewProvider(module.InventoryProviderConfig{Type: "REST", Url: address + "/cmdb/hosts", Token: "secret"}, "")
+	if err != nil {
+		t.Fatalf("Unable to create provider: %v", err)
+	}
+	groups, err := provider.GetHostGroups()
+	if err != nil {
+		t.Fatalf("Unable to get host groups: %v", err)
+	}
+	if names := groupNames(groups); names != "web(2)" {
+		t.Fatalf("Unexpected host groups: %s", names)
+	}
+	if server.paths[0] != "/cmdb/hosts" || server.headers[0].Get("Authorization") != "Bearer secret" {
+		t.Fatalf("Unexpected request: %s %v", server.paths[0], server.headers[0])
+	}
+	server.answer(http.StatusOK, `[{"name":"db","hosts":[{"name":"db-01"}]}]`)
+	if groups, err = provider.GetHostGroups(); err != nil || groupNames(groups) != "db(1)" {
+		t.Fatalf("Unexpected host groups list: %v %v", groups, err)
+	}
+	server.answer(http.StatusInternalServerError, "database unavailable")
+	if _, err = provider.GetHostGroups(); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("Expected a status error, got %v", err)
+	}
+	server.answer(http.StatusOK, "not json")
+	if _, err = provider.GetHostGroups(); err == nil {
+		t.Fatal("Expected a parse error")
+	}
+}
+
+func TestConsulProvider(t *testing.T) {
+	var body string = fmt.Sprintf(`[
+		{"Key":"go-deploy/inventory/","Value":null},
+		{"Key":"go-deploy/inventory/web","Value":"%s"},
+		{"Key":"go-deploy/inventory/db","Value":"%s"},
+		{"Key":"go-deploy/inventory/more","Value":"%s"}
+	]`, consulValue(`{"hosts":[{"name":"web-01"}]}`),
+		consulValue(`{"name":"database","hosts":[{"name":"db-01"},{"name":"db-02"}]}`),
+		consulValue(`[{"name":"cache","hosts":[{"name":"cache-01"}]}]`))
+	server, address := newInventoryServer(t, http.StatusOK, body)
+	provider, err := NewProvider(module.InventoryProviderConfig{Type: module.INVENTORY_PROVIDER_CONSUL, Url: address + "/", Token: "secret"}, "")
+	if err != nil {
+		t.Fatalf("Unable to create provider: %v", err)
+	}
+	groups, err := provider.GetHostGroups()
+	if err != nil {
+		t.Fatalf("Unable to get host groups: %v", err)
+	}
+	// The group name is the last key segment when missing
+	if names := groupNames(groups); names != "web(1),database(2),cache(1)" {
+		t.Fatalf("Unexpected host groups: %s", names)
+	}
+	if server.paths[0] != "/v1/kv/go-deploy/inventory?recurse=true" || server.headers[0].Get("X-Consul-Token") != "secret" {
+		t.Fatalf("Unexpected request: %s %v", server.paths[0], server.headers[0])
+	}
+	server.answer(http.StatusNotFound, "")
+	if groups, err = provider.GetHostGroups(); err != nil || len(groups) != 0 {
+		t.Fatalf("Expected no host groups without keys, got %v %v", groups, err)
+	}
+	server.answer(http.StatusForbidden, "ACL not found")
+	if _, err = provider.GetHostGroups(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Expected a status error, got %v", err)
+	}
+	server.answer(http.StatusOK, fmt.Sprintf(`[{"Key":"go-deploy/inventory/web","Value":"%s"}]`, consulValue("{")))
+	if _, err = provider.GetHostGroups(); err == nil || !strings.Contains(err.Error(), "go-deploy/inventory/web") {
+		t.Fatalf("Expected a key parse error, got %v", err)
+	}
+}
+
+func TestConsulProviderPrefix(t *testing.T) {
+	server, address := newInventoryServer(t, http.StatusNotFound, "")
+	provider, err := NewProvider(module.InventoryProviderConfig{Type: module.INVENTORY_PROVIDER_CONSUL, Url: address, Prefix: "/teams/ops team/"}, "")
+	if err != nil {
+		t.Fatalf("Unable to create provider: %v", err)
+	}
+	if _, err = provider.GetHostGroups(); err != nil {
+		t.Fatalf("Unable to get host groups: %v", err)
+	}
+	if server.paths[0] != "/v1/kv/teams/ops%20team?recurse=true" {
+		t.Fatalf("Unexpected request: %s", server.paths[0])
+	}
+}
+
+func TestLoadProvidersUsesCacheWhenProviderFails(t *testing.T) {
+	server, address := newInventoryServer(t, http.StatusOK, `[{"name":"web","hosts":[{"name":"web-01"}]}]`)
+	var config *module.InventoryConfig = &module.InventoryConfig{
+		Providers: []module.InventoryProviderConfig{{Name: "cmdb", Type: module.INVENTORY_PROVIDER_REST, Url: address}},
+	}
+	var systemDir string = t.TempDir()
+	// Cached results expire immediately, the provider is always queried
+	groups, err := LoadProviders(config, "", systemDir, time.Nanosecond)
+	if err != nil || groupNames(groups) != "web(1)" {
+		t.Fatalf("Unexpected host groups: %v %v", groups, err)
+	}
+	server.answer(http.StatusServiceUnavailable, "")
+	groups, err = LoadProviders(config, "", systemDir, time.Nanosecond)
+	if err != nil || groupNames(groups) != "web(1)" {
+		t.Fatalf("Expected the cached host groups, got %v %v", groups, err)
+	}
+	if len(server.paths) != 2 {
+		t.Fatalf("Expected 2 requests, got %v", len(server.paths))
+	}
+	// Without cache the provider failure is retrieved
+	if _, err = LoadProviders(config, "", t.TempDir(), time.Nanosecond); err == nil || !strings.Contains(err.Error(), "cmdb") {
+		t.Fatalf("Expected a provider error, got %v", err)
+	}
+}
+
+func TestLoadProvidersUsesFreshCache(t *testing.T) {
+	server, address := newInventoryServer(t, http.StatusOK, `[{"name":"web","hosts":[{"name":"web-01"}]}]`)
+	var config *module.InventoryConfig = &module.InventoryConfig{
+		Providers: []module.InventoryProviderConfig{{Type: module.INVENTORY_PROVIDER_REST, Url: address}},
+	}
+	var systemDir string = t.TempDir()
+	for index := 0; index < 2; index++ {
+		if groups, err := LoadProviders(config, "", systemDir, time.Hour); err != nil || groupNames(groups) != "web(1)" {
+			t.Fatalf("Unexpected host groups: %v %v", groups, err)
+		}
+	}
+	if len(server.paths) != 1 {
+		t.Fatalf("Expected a single request, got %v", len(server.paths))
+	}
+}
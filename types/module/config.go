@@ -2,6 +2,7 @@ package module
 
 import (
 	"github.com/hellgate75/go-tcp-common/log"
+	"time"
 )
 
 var Logger log.Logger = nil
@@ -14,6 +15,7 @@ type DescriptorTypeValue string
 type StrategyTypeValue string
 type RestMethodTypeValue string
 type NetProtocolTypeValue string
+type HostKeyCheckingValue string
 
 const (
 	unknownSource deploySourceTypeValue = 0
@@ -64,6 +66,14 @@ const (
 	NET_PROTOCOL_SSH              NetProtocolTypeValue = "SSH"
 	// Go! TLS/TCP Client protocol
 	NET_PROTOCOL_GO_DEPLOY_CLIENT NetProtocolTypeValue = "GO_DEPLOY"
+	// Local machine protocol, hosts commands and file transfers run locally
+	NET_PROTOCOL_LOCAL            NetProtocolTypeValue = "LOCAL"
+	// Host keys must be present in the known hosts file or pinned in the hosts file
+	HOST_KEY_CHECKING_STRICT      HostKeyCheckingValue = "strict"
+	// Unknown host keys are accepted and recorded in the known hosts file, changed host keys are refused
+	HOST_KEY_CHECKING_TOFU        HostKeyCheckingValue = "trust-on-first-use"
+	// Host keys are not verified
+	HOST_KEY_CHECKING_INSECURE    HostKeyCheckingValue = "insecure"
 )
 
 // Deploy Behaviour Configuration Struture
@@ -74,18 +84,28 @@ type DeployType struct {
 	Scheduled      string              `yaml:"scheduled,omitempty" json:"scheduled,omitempty" json:"scheduled,chardata,omitempty"`
 	Method         RestMethodTypeValue `yaml:"restMethod,omitempty" json:"restMethod,omitempty" json:"restMethod,chardata,omitempty"`
 	PostBody       string              `yaml:"postBody,omitempty" json:"postBody,omitempty" json:"post-body,chardata,omitempty"`
+	BaseUrl        string              `yaml:"baseUrl,omitempty" json:"baseUrl,omitempty" xml:"base-url,chardata,omitempty"`
+	CaCert         string              `yaml:"caCert,omitempty" json:"caCert,omitempty" xml:"ca-cert,chardata,omitempty"`
+	Certificate    string              `yaml:"certificate,omitempty" json:"certificate,omitempty" xml:"certificate,chardata,omitempty"`
+	KeyFile        string              `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
+	Insecure       bool                `yaml:"insecure,omitempty" json:"insecure,omitempty" xml:"insecure,chardata,omitempty"`
+	Listen         string              `yaml:"listen,omitempty" json:"listen,omitempty" xml:"listen,chardata,omitempty"`
 }
 
 // Networking and Client Configuration Struture
 type NetProtocolType struct {
-	NetProtocol NetProtocolTypeValue `yaml:"protocol,omitempty" json:"protocol,omitempty" xml:"protocol,chardata,omitempty"`
-	UserName    string               `yaml:"userName,omitempty" json:"userName,omitempty" xml:"username,chardata,omitempty"`
-	Password    string               `yaml:"password,omitempty" json:"password,omitempty" xml:"password,chardata,omitempty"`
-	KeyFile     string               `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
-	CaCert      string               `yaml:"caCert,omitempty" json:"caCert,omitempty" xml:"ca-cert,chardata,omitempty"`
-	Passphrase  string               `yaml:"passphrase,omitempty" json:"passphrase,omitempty" xml:"passphrase,chardata,omitempty"`
-	Certificate string               `yaml:"certificate,omitempty" json:"certificate,omitempty" xml:"certificate,chardata,omitempty"`
-	Insecure	bool               	  `yaml:"insecure,omitempty" json:"insecure,omitempty" xml:"insecure,chardata,omitempty"`
+	NetProtocol     NetProtocolTypeValue `yaml:"protocol,omitempty" json:"protocol,omitempty" xml:"protocol,chardata,omitempty"`
+	UserName        string               `yaml:"userName,omitempty" json:"userName,omitempty" xml:"username,chardata,omitempty"`
+	Password        string               `yaml:"password,omitempty" json:"password,omitempty" xml:"password,chardata,omitempty"`
+	KeyFile         string               `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
+	CaCert          string               `yaml:"caCert,omitempty" json:"caCert,omitempty" xml:"ca-cert,chardata,omitempty"`
+	Passphrase      string               `yaml:"passphrase,omitempty" json:"passphrase,omitempty" xml:"passphrase,chardata,omitempty"`
+	Certificate     string               `yaml:"certificate,omitempty" json:"certificate,omitempty" xml:"certificate,chardata,omitempty"`
+	Insecure        bool                 `yaml:"insecure,omitempty" json:"insecure,omitempty" xml:"insecure,chardata,omitempty"`
+	UseSSHConfig    bool                 `yaml:"useSSHConfig,omitempty" json:"useSSHConfig,omitempty" xml:"use-ssh-config,chardata,omitempty"`
+	SSHConfigFile   string               `yaml:"sshConfigFile,omitempty" json:"sshConfigFile,omitempty" xml:"ssh-config-file,chardata,omitempty"`
+	HostKeyChecking HostKeyCheckingValue `yaml:"hostKeyChecking,omitempty" json:"hostKeyChecking,omitempty" xml:"host-key-checking,chardata,omitempty"`
+	KnownHostsFile  string               `yaml:"knownHostsFile,omitempty" json:"knownHostsFile,omitempty" xml:"known-hosts-file,chardata,omitempty"`
 }
 
 // Main Configuration Struture
@@ -105,6 +125,9 @@ type DeployConfig struct {
 	MaxThreads         int64               `yaml:"maxThreads,omitempty" json:"maxThreads,omitempty" xml:"max-threads,chardata,omitempty"`
 	SingleSession      bool                `yaml:"singleSession,omitempty" json:"singleSession,omitempty" xml:"single-session,chardata,omitempty"`
 	ReadTimeout      int64                `yaml:"readTimeout,omitempty" json:"readTimeout,omitempty" xml:"read-timeout,chardata,omitempty"`
+	CheckMode          bool                `yaml:"checkMode,omitempty" json:"checkMode,omitempty" xml:"check-mode,chardata,omitempty"`
+	Limit              string              `yaml:"limit,omitempty" json:"limit,omitempty" xml:"limit,chardata,omitempty"`
+	InventoryTTL       string              `yaml:"inventoryTtl,omitempty" json:"inventoryTtl,omitempty" xml:"inventory-ttl,chardata,omitempty"`
 }
 
 // Plugins Configuration Struture
@@ -120,6 +143,35 @@ type PluginsConfig struct {
 	DeployClientCommandsPluginFolder    string `yaml:"deployClientCommandsPluginFolder,omitempty" json:"deployClientCommandsPluginFolder,omitempty" xml:"deploy-client-commands-plugin-folder,chardata,omitempty"`
 }
 
+// Inventory provider type
+type InventoryProviderTypeValue string
+
+const (
+	// REST endpoint (e.g.: CMDB) answering with hosts file content or a list of host groups, in JSON format
+	INVENTORY_PROVIDER_REST   InventoryProviderTypeValue = "rest"
+	// Consul-like KV HTTP API, each key under the prefix contains a host group in JSON format
+	INVENTORY_PROVIDER_CONSUL InventoryProviderTypeValue = "consul"
+)
+
+// Inventory Provider Configuration Structure
+type InventoryProviderConfig struct {
+	Name        string                     `yaml:"name,omitempty" json:"name,omitempty" xml:"name,chardata,omitempty"`
+	Type        InventoryProviderTypeValue `yaml:"type,omitempty" json:"type,omitempty" xml:"type,chardata,omitempty"`
+	Url         string                     `yaml:"url,omitempty" json:"url,omitempty" xml:"url,chardata,omitempty"`
+	Prefix      string                     `yaml:"prefix,omitempty" json:"prefix,omitempty" xml:"prefix,chardata,omitempty"`
+	Token       string                     `yaml:"token,omitempty" json:"token,omitempty" xml:"token,chardata,omitempty"`
+	Timeout     string                     `yaml:"timeout,omitempty" json:"timeout,omitempty" xml:"timeout,chardata,omitempty"`
+	CaCert      string                     `yaml:"caCert,omitempty" json:"caCert,omitempty" xml:"ca-cert,chardata,omitempty"`
+	Certificate string                     `yaml:"certificate,omitempty" json:"certificate,omitempty" xml:"certificate,chardata,omitempty"`
+	KeyFile     string                     `yaml:"keyFile,omitempty" json:"keyFile,omitempty" xml:"key-file,chardata,omitempty"`
+	Insecure    bool                       `yaml:"insecure,omitempty" json:"insecure,omitempty" xml:"insecure,chardata,omitempty"`
+}
+
+// Inventory Configuration Structure
+type InventoryConfig struct {
+	Providers []InventoryProviderConfig `yaml:"providers,omitempty" json:"providers,omitempty" xml:"provider,omitempty"`
+}
+
 // Printable interface, allows system to print as sting any implementing components (almost all in this project)
 type Printable interface {
 	// Traslates the object in printable version <BR/>
@@ -133,15 +185,49 @@ type Step struct {
 	Name     string
 	StepType string
 	StepData interface{}
+	// Module arguments as read from the feed, loop steps convert them again for each item
+	Data     interface{}
 	Children []*Step
 	Feeds    []*FeedExec
+	// Condition expression over session variables, the step is skipped on hosts where it's false
+	When     string
+	// Items the step runs on, one run per item, nil when the step doesn't loop
+	Loop     []string
+	// Session variable that contains the items the step runs on, when Loop is empty
+	LoopVar  string
+	// Number of times the step is run again on failing hosts
+	Retries  int
+	// Wait time before the first retry
+	Delay    time.Duration
+	// Multiplier of the wait time between consecutive retries
+	Backoff  float64
+	// Condition expression over session variables, the step is retried on hosts where it's false
+	Until    string
+	// Maximum duration of the step on each host, the host fails when it expires
+	Timeout  time.Duration
+	// Failures of the step are reported, but hosts are not marked as failed
+	IgnoreErrors bool
+	// Roles selector, the step runs only on the hosts with matching roles
+	Roles    string
 }
 
 // Executable Feed Structure
 type FeedExec struct {
 	Name      string
 	HostGroup string
+	// Roles selector, the feed runs only on the group hosts with matching roles
+	Roles     string
 	Steps     []*Step
+	// Maximum duration of the feed execution, imported feeds included
+	Timeout   time.Duration
+	// Aborts the feed after the first step with failed hosts
+	FailFast  bool
+	// Aborts the feed when the failed hosts percentage exceeds this value, 0 disables the check
+	MaxFailPercentage float64
+	// Number of hosts per batch, the full steps list runs on a batch before the next one starts
+	SerialCount       int
+	// Percentage of the group hosts per batch, used when SerialCount is 0
+	SerialPercentage  float64
 }
 
 // Session Interface
@@ -158,6 +244,8 @@ type Session interface {
 	GetVar(name string) (string, error)
 	// Sets a Session Variable
 	SetVar(name string, value string) bool
+	// Removes a Session Variable
+	RemoveVar(name string) bool
 	// Retrives all Session Variable keys
 	GetKeys() []string
 	// Retrives a Session Object by key
@@ -168,12 +256,14 @@ type Session interface {
 	// Retrives all Session Object keys
 	// Build-in session objects :
 	// connection-handler -> Current Session ConnectionHandler
+	// connection-error -> Error creating the Session ConnectionHandler, the host fails and runs no step
+	// connection-config -> Session host module.ConnectionConfig
 	// rutime-config -> Session module.DeployConfig
 	// runtime-type -> Session module.DeployType
-	// runtime-net -> Session module.NetworkType
+	// runtime-net -> Session host module.NetworkType, with host group and host connection overrides
 	// host-groups -> Current Running defaults.HostGroup
 	// envs -> Session Environemnts Row defaults.NameValuePair list
-	// vars -> Session Variables Row defaults.NameValuePair list
+	// vars -> Session host Variables Row defaults.NameValuePair list, with host group, host and extra variables
 	// system-logger -> Centrilized Go! Deploy logger instance
 	GetSystemKeys() []string
 }
@@ -16,6 +16,7 @@ var RuntimeDeployConfig *DeployConfig = nil
 var RuntimeDeployType *DeployType = nil
 var RuntimeNetworkType *NetProtocolType = nil
 var RuntimePluginsType *PluginsConfig = nil
+var RuntimeInventoryConfig *InventoryConfig = nil
 
 var ChartsDescriptorFormat DescriptorTypeValue = DescriptorTypeValue("YAML")
 
@@ -71,6 +72,20 @@ func (session *session) SetVar(name string, value string) bool {
 	sessionVars[session.sessionId][name] = value
 	return out
 }
+func (session *session) RemoveVar(name string) bool {
+	defer func() {
+		if r := recover(); r != nil {
+			Logger.Errorf("Session.RemoveVar : %v", r)
+		}
+		session.Unlock()
+	}()
+	session.Lock()
+	if _, ok := sessionVars[session.sessionId][name]; ok {
+		delete(sessionVars[session.sessionId], name)
+		return true
+	}
+	return false
+}
 func (session *session) GetKeys() []string {
 	defer func() {
 		if r := recover(); r != nil {
@@ -261,6 +276,110 @@ func (pc *PluginsConfig) FromJsonCode(jsonCode string) (*PluginsConfig, error) {
 	return conf, nil
 }
 
+// Merges the inventory providers, providers of the given configuration replace the ones with the same name
+func (ic *InventoryConfig) Merge(ic2 *InventoryConfig) *InventoryConfig {
+	var providers []InventoryProviderConfig = make([]InventoryProviderConfig, 0)
+	var indexes map[string]int = make(map[string]int)
+	for _, conf := range []*InventoryConfig{ic, ic2} {
+		if conf == nil {
+			continue
+		}
+		for _, provider := range conf.Providers {
+			if index, ok := indexes[provider.Name]; ok && provider.Name != "" {
+				providers[index] = provider
+				continue
+			}
+			indexes[provider.Name] = len(providers)
+			providers = append(providers, provider)
+		}
+	}
+	return &InventoryConfig{
+		Providers: providers,
+	}
+}
+
+func (ic *InventoryConfig) String() string {
+	var providers string = ""
+	for _, provider := range ic.Providers {
+		if providers != "" {
+			providers += ", "
+		}
+		providers += provider.String()
+	}
+	return fmt.Sprintf("InventoryConfig{Providers: [%s]}", providers)
+}
+
+func (ipc InventoryProviderConfig) String() string {
+	return fmt.Sprintf("InventoryProviderConfig{Name: \"%s\", Type: \"%s\", Url: \"%s\", Prefix: \"%s\", Timeout: \"%s\", CaCert: \"%s\", Certificate: \"%s\", KeyFile: \"%s\", Insecure: %v}",
+		ipc.Name, ipc.Type, ipc.Url, ipc.Prefix, ipc.Timeout, ipc.CaCert, ipc.Certificate, ipc.KeyFile, ipc.Insecure)
+}
+
+func (ic *InventoryConfig) Yaml() (string, error) {
+	return io.ToYaml(ic)
+}
+
+func (ic *InventoryConfig) FromYamlFile(path string) (*InventoryConfig, error) {
+	itf, err := io.FromYamlFile(path, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
+func (ic *InventoryConfig) FromYamlCode(yamlCode string) (*InventoryConfig, error) {
+	itf, err := io.FromYamlCode(yamlCode, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
+func (ic *InventoryConfig) Xml() (string, error) {
+	return io.ToXml(ic)
+}
+
+func (ic *InventoryConfig) FromXmlFile(path string) (*InventoryConfig, error) {
+	itf, err := io.FromXmlFile(path, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
+func (ic *InventoryConfig) FromXmlCode(xmlCode string) (*InventoryConfig, error) {
+	itf, err := io.FromXmlCode(xmlCode, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
+func (ic *InventoryConfig) Json() (string, error) {
+	return io.ToJson(ic)
+}
+
+func (ic *InventoryConfig) FromJsonFile(path string) (*InventoryConfig, error) {
+	itf, err := io.FromJsonFile(path, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
+func (ic *InventoryConfig) FromJsonCode(jsonCode string) (*InventoryConfig, error) {
+	itf, err := io.FromJsonCode(jsonCode, ic)
+	if err != nil {
+		return nil, err
+	}
+	var conf *InventoryConfig = itf.(*InventoryConfig)
+	return conf, nil
+}
+
 func (dt *DeployType) Merge(dt2 *DeployType) *DeployType {
 	return &DeployType{
 		DeploymentType: DeploymentTypeValue(bestString(string(dt2.DeploymentType), string(dt.DeploymentType))),
@@ -269,12 +388,18 @@ func (dt *DeployType) Merge(dt2 *DeployType) *DeployType {
 		Method:         RestMethodTypeValue(bestString(string(dt2.Method), string(dt.Method))),
 		Scheduled:      bestString(dt2.Scheduled, dt.Scheduled),
 		PostBody:       bestString(dt2.PostBody, dt.PostBody),
+		BaseUrl:        bestString(dt2.BaseUrl, dt.BaseUrl),
+		CaCert:         bestString(dt2.CaCert, dt.CaCert),
+		Certificate:    bestString(dt2.Certificate, dt.Certificate),
+		KeyFile:        bestString(dt2.KeyFile, dt.KeyFile),
+		Insecure:       dt2.Insecure || dt.Insecure,
+		Listen:         bestString(dt2.Listen, dt.Listen),
 	}
 }
 
 func (dt *DeployType) String() string {
-	return fmt.Sprintf("DeployType{DeploymentType: \"%v\", DescriptorType: %v, StrategyType: %v, Method: \"%v\", Scheduled: \"%v\", PostBody: \"%v\"}",
-		dt.DeploymentType, dt.DescriptorType, dt.StrategyType, dt.Method, dt.Scheduled, dt.PostBody)
+	return fmt.Sprintf("DeployType{DeploymentType: \"%v\", DescriptorType: %v, StrategyType: %v, Method: \"%v\", Scheduled: \"%v\", PostBody: \"%v\", BaseUrl: \"%s\", CaCert: \"%s\", Certificate: \"%s\", KeyFile: \"%s\", Insecure: %v, Listen: \"%s\"}",
+		dt.DeploymentType, dt.DescriptorType, dt.StrategyType, dt.Method, dt.Scheduled, dt.PostBody, dt.BaseUrl, dt.CaCert, dt.Certificate, dt.KeyFile, dt.Insecure, dt.Listen)
 }
 
 func (dt *DeployType) Yaml() (string, error) {
@@ -353,12 +478,16 @@ func (npt *NetProtocolType) Merge(npt2 *NetProtocolType) *NetProtocolType {
 		Password:    bestString(npt2.Password, npt.Password),
 		Certificate: bestString(npt2.Certificate, npt.Certificate),
 		Insecure: 	 npt2.Insecure || npt.Insecure,
+		UseSSHConfig: npt2.UseSSHConfig || npt.UseSSHConfig,
+		SSHConfigFile: bestString(npt2.SSHConfigFile, npt.SSHConfigFile),
+		HostKeyChecking: HostKeyCheckingValue(bestString(string(npt2.HostKeyChecking), string(npt.HostKeyChecking))),
+		KnownHostsFile: bestString(npt2.KnownHostsFile, npt.KnownHostsFile),
 	}
 }
 
 func (npt *NetProtocolType) String() string {
-	return fmt.Sprintf("NetProtocolType{NetProtocol: \"%v\", UserName: \"%s\", Password: \"%s\", KeyFile: \"%s\", CaCert: \"%s\", Passphrase: \"%s\", Insecure: %v:}",
-		npt.NetProtocol, npt.UserName, npt.Password, npt.KeyFile, npt.CaCert, npt.Passphrase, npt.Insecure)
+	return fmt.Sprintf("NetProtocolType{NetProtocol: \"%v\", UserName: \"%s\", Password: \"%s\", KeyFile: \"%s\", CaCert: \"%s\", Passphrase: \"%s\", Insecure: %v, UseSSHConfig: %v, SSHConfigFile: \"%s\", HostKeyChecking: \"%v\", KnownHostsFile: \"%s\"}",
+		npt.NetProtocol, npt.UserName, npt.Password, npt.KeyFile, npt.CaCert, npt.Passphrase, npt.Insecure, npt.UseSSHConfig, npt.SSHConfigFile, npt.HostKeyChecking, npt.KnownHostsFile)
 }
 
 func (npt *NetProtocolType) Yaml() (string, error) {
@@ -444,14 +573,17 @@ func (dc *DeployConfig) Merge(dc2 *DeployConfig) *DeployConfig {
 		MaxThreads:         maxInt64(dc2.MaxThreads, dc.MaxThreads),
 		SingleSession:		dc2.SingleSession || dc.SingleSession,
 		ReadTimeout:        maxInt64(dc2.ReadTimeout, dc.ReadTimeout),
+		CheckMode:          dc2.CheckMode || dc.CheckMode,
+		Limit:              bestString(dc2.Limit, dc.Limit),
+		InventoryTTL:       bestString(dc2.InventoryTTL, dc.InventoryTTL),
 		UseHosts:           useHosts,
 		UseVars:            useVars,
 	}
 }
 
 func (dc *DeployConfig) String() string {
-	return fmt.Sprintf("DeployConfig{DeployName: \"%s\", UseHosts: %v, UseVars: %v, WorkDir: \"%s\", ConfigDir: \"%s\", ChartsDir: \"%s\", SystemDir: \"%s\", ModulesDir: \"%s\", ConfigLang: \"%v\", LogVerbosity: \"%v\", EnvSelector: \"%s\", SingleSession: %v, ParallelExecutions: %v, MaxThreads: %vm ReadTimeout: %v}",
-		dc.DeployName, dc.UseHosts, dc.UseVars, dc.WorkDir, dc.ConfigDir, dc.ChartsDir, dc.SystemDir, dc.ModulesDir, dc.ConfigLang, dc.LogVerbosity, dc.EnvSelector, dc.SingleSession, dc.ParallelExecutions, dc.MaxThreads, dc.ReadTimeout)
+	return fmt.Sprintf("DeployConfig{DeployName: \"%s\", UseHosts: %v, UseVars: %v, WorkDir: \"%s\", ConfigDir: \"%s\", ChartsDir: \"%s\", SystemDir: \"%s\", ModulesDir: \"%s\", ConfigLang: \"%v\", LogVerbosity: \"%v\", EnvSelector: \"%s\", SingleSession: %v, ParallelExecutions: %v, MaxThreads: %vm ReadTimeout: %v, CheckMode: %v, Limit: \"%s\", InventoryTTL: \"%s\"}",
+		dc.DeployName, dc.UseHosts, dc.UseVars, dc.WorkDir, dc.ConfigDir, dc.ChartsDir, dc.SystemDir, dc.ModulesDir, dc.ConfigLang, dc.LogVerbosity, dc.EnvSelector, dc.SingleSession, dc.ParallelExecutions, dc.MaxThreads, dc.ReadTimeout, dc.CheckMode, dc.Limit, dc.InventoryTTL)
 }
 
 func (dc *DeployConfig) Yaml() (string, error) {
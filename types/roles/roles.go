@@ -0,0 +1,251 @@
+package roles
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Hosts roles selector, e.g.: web & !canary.
+// Supported operators are: & (&&, and) for intersections, | (||, or, comma) for unions, ! (not) for exclusions and
+// parenthesis. Role names are matched case insensitively and support glob wildcards (e.g.: db-*)
+type Selector struct {
+	text string
+	root node
+}
+
+type node interface {
+	match(roles []string) bool
+}
+
+type roleNode struct {
+	pattern string
+}
+
+func (n *roleNode) match(roles []string) bool {
+	for _, role := range roles {
+		var name string = strings.ToLower(strings.TrimSpace(role))
+		if name == n.pattern {
+			return true
+		}
+		if ok, err := path.Match(n.pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) match(roles []string) bool {
+	return !n.operand.match(roles)
+}
+
+type binaryNode struct {
+	and   bool
+	left  node
+	right node
+}
+
+func (n *binaryNode) match(roles []string) bool {
+	if n.and {
+		return n.left.match(roles) && n.right.match(roles)
+	}
+	return n.left.match(roles) || n.right.match(roles)
+}
+
+// Verifies if the given host roles match the selector
+func (selector *Selector) Matches(roles []string) bool {
+	if selector == nil || selector.root == nil {
+		return true
+	}
+	return selector.root.match(roles)
+}
+
+// Retrieves the selector text
+func (selector *Selector) String() string {
+	if selector == nil {
+		return ""
+	}
+	return selector.text
+}
+
+// Parses a roles selector
+func Parse(text string) (*Selector, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("Empty roles selector")
+	}
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid roles selector: %s -> %s", text, err.Error()))
+	}
+	var p *parser = &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.index < len(p.tokens) {
+		err = errors.New(fmt.Sprintf("Unexpected token '%s'", strings.TrimPrefix(p.tokens[p.index], "=")))
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid roles selector: %s -> %s", text, err.Error()))
+	}
+	return &Selector{
+		text: text,
+		root: root,
+	}, nil
+}
+
+// Combines selectors texts in a single selector text, where all of them must match
+func And(texts ...string) string {
+	var parts []string = make([]string, 0)
+	for _, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	var out string = ""
+	for _, part := range parts {
+		if out != "" {
+			out += " & "
+		}
+		out += "(" + part + ")"
+	}
+	return out
+}
+
+// Operators aliases, keywords are matched case insensitively
+var operators map[string]string = map[string]string{
+	"&":   "&",
+	"&&":  "&",
+	"and": "&",
+	"|":   "|",
+	"||":  "|",
+	",":   "|",
+	"or":  "|",
+	"!":   "!",
+	"not": "!",
+	"(":   "(",
+	")":   ")",
+}
+
+func isSeparator(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '&' || c == '|' || c == ',' || c == '!' || c == '(' || c == ')'
+}
+
+// Splits the selector in operators and role names, operators are retrieved in their canonical form
+func tokenize(text string) ([]string, error) {
+	var tokens []string = make([]string, 0)
+	for index := 0; index < len(text); {
+		var c byte = text[index]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			index++
+			continue
+		}
+		if index+1 < len(text) && (text[index:index+2] == "&&" || text[index:index+2] == "||") {
+			tokens = append(tokens, operators[text[index:index+2]])
+			index += 2
+			continue
+		}
+		if isSeparator(c) {
+			tokens = append(tokens, operators[string(c)])
+			index++
+			continue
+		}
+		var start int = index
+		for index < len(text) && !isSeparator(text[index]) {
+			index++
+		}
+		var name string = strings.ToLower(text[start:index])
+		if operator, ok := operators[name]; ok {
+			tokens = append(tokens, operator)
+			continue
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid role pattern '%s' at position %v", text[start:index], start))
+		}
+		// Role names are prefixed to be distinguished from operators
+		tokens = append(tokens, "="+name)
+	}
+	return tokens, nil
+}
+
+// Recursive descent parser, operators precedence from lowest: |, &, !
+type parser struct {
+	tokens []string
+	index  int
+}
+
+func (p *parser) accept(operator string) bool {
+	if p.index < len(p.tokens) && p.tokens[p.index] == operator {
+		p.index++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{false, left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{true, left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	}
+	return p.parseRole()
+}
+
+func (p *parser) parseRole() (node, error) {
+	if p.index >= len(p.tokens) {
+		return nil, errors.New("Unexpected end of selector")
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, errors.New("Missing ')'")
+		}
+		return inner, nil
+	}
+	var t string = p.tokens[p.index]
+	if !strings.HasPrefix(t, "=") {
+		return nil, errors.New(fmt.Sprintf("Unexpected token '%s'", t))
+	}
+	p.index++
+	return &roleNode{strings.TrimPrefix(t, "=")}, nil
+}
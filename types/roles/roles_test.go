@@ -0,0 +1,99 @@
+package roles
+
+import (
+	"testing"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	for _, test := range []struct {
+		selector string
+		roles    []string
+		expected bool
+	}{
+		{"web", []string{"web", "canary"}, true},
+		{"WEB", []string{" Web "}, true},
+		{"db", []string{"web"}, false},
+		{"db-*", []string{"db-primary"}, true},
+		{"db-?", []string{"db-primary"}, false},
+		// Intersections
+		{"web & canary", []string{"web", "canary"}, true},
+		{"web && canary", []string{"web"}, false},
+		{"web and canary", []string{"canary"}, false},
+		// Unions
+		{"web | db", []string{"db"}, true},
+		{"web || db", []string{"cache"}, false},
+		{"web or db", []string{"web"}, true},
+		{"web,db", []string{"db"}, true},
+		// Exclusions
+		{"!canary", []string{"web"}, true},
+		{"not canary", []string{"canary"}, false},
+		{"web & !canary", []string{"web", "canary"}, false},
+		{"web & !canary", []string{"web"}, true},
+		{"!!web", []string{"web"}, true},
+		// & binds tighter than |, ! tighter than &
+		{"db | web & canary", []string{"db"}, true},
+		{"db | web & canary", []string{"web"}, false},
+		{"(db | web) & canary", []string{"db"}, false},
+		{"!web | db", []string{"web", "db"}, true},
+		{"!(web | db)", []string{"db"}, false},
+		// Hosts without roles
+		{"web", nil, false},
+		{"web", []string{}, false},
+		{"!web", []string{}, true},
+		{"web | !db", nil, true},
+	} {
+		selector, err := Parse(test.selector)
+		if err != nil {
+			t.Fatalf("Unable to parse %s: %v", test.selector, err)
+		}
+		if result := selector.Matches(test.roles); result != test.expected {
+			t.Errorf("%s on %v: expected %v, got %v", test.selector, test.roles, test.expected, result)
+		}
+	}
+}
+
+func TestNilSelectorMatchesAll(t *testing.T) {
+	var selector *Selector = nil
+	if !selector.Matches(nil) || !selector.Matches([]string{"web"}) {
+		t.Fatal("Expected nil selector matching all roles")
+	}
+	if selector.String() != "" {
+		t.Fatalf("Unexpected nil selector text: %s", selector.String())
+	}
+}
+
+func TestInvalidSelectors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"  ",
+		"web &",
+		"| web",
+		"!",
+		"(web",
+		"web)",
+		"()",
+		"web db",
+		"web & & db",
+		"db-[",
+	} {
+		if selector, err := Parse(text); err == nil {
+			t.Errorf("%q: expected a parse error, got %v", text, selector)
+		}
+	}
+}
+
+func TestAnd(t *testing.T) {
+	for _, test := range []struct {
+		texts    []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"web"}, "web"},
+		{[]string{"", "web", " "}, "web"},
+		{[]string{"web | db", "!canary"}, "(web | db) & (!canary)"},
+	} {
+		if result := And(test.texts...); result != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.texts, test.expected, result)
+		}
+	}
+}
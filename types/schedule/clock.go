@@ -0,0 +1,32 @@
+package schedule
+
+import (
+	"github.com/hellgate75/go-tcp-common/log"
+	"time"
+)
+
+var Logger log.Logger = nil
+
+// Clock interface, source of the time used by the schedules, it allows to replace the system time
+type Clock interface {
+	// Retrieves the current time
+	Now() time.Time
+	// Retrieves a channel that receives the time after the given duration
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct {
+}
+
+func (clock *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (clock *systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Creates a new Clock based on the system time
+func NewSystemClock() Clock {
+	return &systemClock{}
+}
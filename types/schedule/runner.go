@@ -0,0 +1,52 @@
+package schedule
+
+import (
+	"fmt"
+)
+
+// Runs a job on each Schedule activation, according to the given Clock.
+// Jobs run one at a time, the next activation is computed when the job completes, so activations falling during a
+// running job are skipped.
+type Runner struct {
+	schedule Schedule
+	clock    Clock
+}
+
+// Runs the job at every schedule activation, until the stop channel is closed or receives a value
+func (runner *Runner) Run(job func(), stop <-chan struct{}) {
+	for {
+		var now = runner.clock.Now()
+		var next = runner.schedule.Next(now)
+		if next.IsZero() {
+			Logger.Error("Schedule has no further activation, exiting ...")
+			return
+		}
+		Logger.Infof("Next scheduled run at: %s", next.Format("2006-01-02 15:04:05 MST"))
+		select {
+		case <-stop:
+			return
+		case <-runner.clock.After(next.Sub(now)):
+			runner.execute(job)
+		}
+	}
+}
+
+func (runner *Runner) execute(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			Logger.Error(fmt.Sprintf("schedule.Runner - Recovery:\n- %v", r))
+		}
+	}()
+	job()
+}
+
+// Creates a new Runner for the given Schedule, using the given Clock or the system clock if nil
+func NewRunner(schedule Schedule, clock Clock) *Runner {
+	if clock == nil {
+		clock = NewSystemClock()
+	}
+	return &Runner{
+		schedule: schedule,
+		clock:    clock,
+	}
+}
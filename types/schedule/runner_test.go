@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"github.com/hellgate75/go-tcp-common/log"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTimer struct {
+	at      time.Time
+	channel chan time.Time
+}
+
+// Clock moved forward by the tests, it reports the durations requested by After calls on the waits channel
+type fakeClock struct {
+	sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  chan time.Duration
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.Lock()
+	defer clock.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) After(d time.Duration) <-chan time.Time {
+	clock.Lock()
+	var channel chan time.Time = make(chan time.Time, 1)
+	clock.timers = append(clock.timers, fakeTimer{clock.now.Add(d), channel})
+	clock.Unlock()
+	clock.waits <- d
+	return channel
+}
+
+// Moves the clock forward, firing the expired timers
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.Lock()
+	defer clock.Unlock()
+	clock.now = clock.now.Add(d)
+	var timers []fakeTimer = make([]fakeTimer, 0)
+	for _, timer := range clock.timers {
+		if !timer.at.After(clock.now) {
+			timer.channel <- clock.now
+		} else {
+			timers = append(timers, timer)
+		}
+	}
+	clock.timers = timers
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{
+		now:    now,
+		timers: make([]fakeTimer, 0),
+		waits:  make(chan time.Duration),
+	}
+}
+
+func setUpRunnerTest(t *testing.T) {
+	var logger log.Logger = Logger
+	Logger = log.NewLogger("test", log.ERROR)
+	t.Cleanup(func() {
+		Logger = logger
+	})
+}
+
+func nextWait(t *testing.T, clock *fakeClock) time.Duration {
+	select {
+	case d := <-clock.waits:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("Runner didn't wait for the next activation")
+	}
+	return 0
+}
+
+func TestRunnerSkipsActivationsDuringJob(t *testing.T) {
+	setUpRunnerTest(t)
+	schedule, err := Parse("*/15 * * * *")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+	var clock *fakeClock = newFakeClock(time.Date(2020, 3, 2, 10, 7, 0, 0, time.UTC))
+	var runs []time.Time = make([]time.Time, 0)
+	var stop chan struct{} = make(chan struct{})
+	var done chan struct{} = make(chan struct{})
+	go func() {
+		NewRunner(schedule, clock).Run(func() {
+			runs = append(runs, clock.Now())
+			// The job lasts longer than the schedule period
+			clock.Advance(20 * time.Minute)
+		}, stop)
+		close(done)
+	}()
+	if d := nextWait(t, clock); d != 8*time.Minute {
+		t.Fatalf("Expected to wait 8m, got %s", d)
+	}
+	clock.Advance(8 * time.Minute)
+	// The 10:30 activation falls during the job, the next one is at 10:45
+	if d := nextWait(t, clock); d != 10*time.Minute {
+		t.Fatalf("Expected to wait 10m, got %s", d)
+	}
+	if len(runs) != 1 || !runs[0].Equal(time.Date(2020, 3, 2, 10, 15, 0, 0, time.UTC)) {
+		t.Fatalf("Expected a single run at 10:15, got %v", runs)
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Runner didn't stop")
+	}
+}
+
+func TestRunnerRecoversFromFailingJob(t *testing.T) {
+	setUpRunnerTest(t)
+	schedule, err := Parse("@every 1m")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+	var clock *fakeClock = newFakeClock(time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC))
+	var runs int = 0
+	var stop chan struct{} = make(chan struct{})
+	var done chan struct{} = make(chan struct{})
+	go func() {
+		NewRunner(schedule, clock).Run(func() {
+			runs++
+			panic("job failure")
+		}, stop)
+		close(done)
+	}()
+	for index := 0; index < 3; index++ {
+		if d := nextWait(t, clock); d != time.Minute {
+			t.Fatalf("Expected to wait 1m, got %s", d)
+		}
+		clock.Advance(time.Minute)
+	}
+	nextWait(t, clock)
+	if runs != 3 {
+		t.Fatalf("Expected 3 runs, got %v", runs)
+	}
+	close(stop)
+	<-done
+}
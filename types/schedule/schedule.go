@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule interface, that computes the activation times
+type Schedule interface {
+	// Retrieves the first activation time strictly after the given time
+	Next(t time.Time) time.Time
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (schedule *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(schedule.interval)
+}
+
+type fieldRange struct {
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	minutesRange  fieldRange = fieldRange{0, 59, nil}
+	hoursRange    fieldRange = fieldRange{0, 23, nil}
+	daysRange     fieldRange = fieldRange{1, 31, nil}
+	monthsRange   fieldRange = fieldRange{1, 12, map[string]uint{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	weekDaysRange fieldRange = fieldRange{0, 7, map[string]uint{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+var descriptors map[string]string = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronSchedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekDays uint64
+	// Days of month and days of week are in OR when both are restricted (not starting with a star)
+	anyDay     bool
+	anyWeekDay bool
+}
+
+func (schedule *cronSchedule) matchDay(t time.Time) bool {
+	var dayMatch bool = schedule.days&(1<<uint(t.Day())) > 0
+	var weekDayMatch bool = schedule.weekDays&(1<<uint(t.Weekday())) > 0
+	if schedule.anyDay || schedule.anyWeekDay {
+		return dayMatch && weekDayMatch
+	}
+	return dayMatch || weekDayMatch
+}
+
+func (schedule *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// No cron expression can wait more than a leap years cycle
+	var limit int = t.Year() + 5
+	for t.Year() <= limit {
+		if schedule.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if schedule.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if schedule.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Parses a schedule expression. It accepts standard 5 fields cron expressions (minute, hour, day of month, month
+// and day of week, with lists, ranges, steps and month / week day names), the descriptors @yearly, @monthly, @weekly,
+// @daily and @hourly, and fixed intervals in the form "@every <duration>" (e.g. "@every 10m")
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("schedule.Parse: Empty schedule expression")
+	}
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("schedule.Parse: Invalid interval in \"%s\", cause: %s", spec, err.Error()))
+		}
+		if interval <= 0 {
+			return nil, errors.New(fmt.Sprintf("schedule.Parse: Interval must be positive in \"%s\"", spec))
+		}
+		return &intervalSchedule{
+			interval: interval,
+		}, nil
+	}
+	if expression, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expression
+	}
+	var fields []string = strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.New(fmt.Sprintf("schedule.Parse: Expected 5 fields in cron expression \"%s\", found %v", spec, len(fields)))
+	}
+	var schedule *cronSchedule = &cronSchedule{
+		anyDay:     fields[2][0] == '*',
+		anyWeekDay: fields[4][0] == '*',
+	}
+	var err error
+	if schedule.minutes, err = parseField(fields[0], minutesRange); err != nil {
+		return nil, err
+	}
+	if schedule.hours, err = parseField(fields[1], hoursRange); err != nil {
+		return nil, err
+	}
+	if schedule.days, err = parseField(fields[2], daysRange); err != nil {
+		return nil, err
+	}
+	if schedule.months, err = parseField(fields[3], monthsRange); err != nil {
+		return nil, err
+	}
+	if schedule.weekDays, err = parseField(fields[4], weekDaysRange); err != nil {
+		return nil, err
+	}
+	// Sunday can be expressed as 7 too
+	if schedule.weekDays&(1<<7) > 0 {
+		schedule.weekDays = (schedule.weekDays | 1) &^ (1 << 7)
+	}
+	return schedule, nil
+}
+
+func parseField(field string, r fieldRange) (uint64, error) {
+	var bits uint64 = 0
+	for _, part := range strings.Split(field, ",") {
+		var step uint = 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			value, err := strconv.ParseUint(part[idx+1:], 10, 8)
+			if err != nil || value == 0 {
+				return 0, errors.New(fmt.Sprintf("schedule.Parse: Invalid step in \"%s\"", part))
+			}
+			step = uint(value)
+			part = part[:idx]
+		}
+		var start uint = r.min
+		var end uint = r.max
+		if part != "*" {
+			var bounds []string = strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = parseValue(bounds[0], r); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseValue(bounds[1], r); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				end = r.max
+			}
+			if end < start {
+				return 0, errors.New(fmt.Sprintf("schedule.Parse: Invalid range \"%s\"", part))
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, r fieldRange) (uint, error) {
+	if r.names != nil {
+		if number, ok := r.names[strings.ToLower(value)]; ok {
+			return number, nil
+		}
+	}
+	number, err := strconv.ParseUint(value, 10, 8)
+	if err != nil || uint(number) < r.min || uint(number) > r.max {
+		return 0, errors.New(fmt.Sprintf("schedule.Parse: Value \"%s\" out of range [%v-%v]", value, r.min, r.max))
+	}
+	return uint(number), nil
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every",
+		"@every 10x",
+		"@every -1m",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected an error parsing \"%s\"", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	var date = func(year int, month time.Month, day int, hour int, minute int, second int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	}
+	for _, test := range []struct {
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"*/15 * * * *", date(2020, 3, 2, 10, 7, 30), date(2020, 3, 2, 10, 15, 0)},
+		{"*/15 * * * *", date(2020, 3, 2, 10, 15, 0), date(2020, 3, 2, 10, 30, 0)},
+		{"0 9 * * mon-fri", date(2020, 3, 7, 10, 0, 0), date(2020, 3, 9, 9, 0, 0)},
+		{"0 2 * * *", date(2020, 12, 31, 3, 0, 0), date(2021, 1, 1, 2, 0, 0)},
+		// Days of month and days of week are in OR when both are restricted
+		{"0 0 1,15 * 0", date(2020, 3, 2, 0, 0, 0), date(2020, 3, 8, 0, 0, 0)},
+		{"0 0 1,15 * 0", date(2020, 3, 9, 0, 0, 0), date(2020, 3, 15, 0, 0, 0)},
+		// Sunday as 7
+		{"30 * * * 7", date(2020, 3, 7, 23, 40, 0), date(2020, 3, 8, 0, 30, 0)},
+		{"0 12 29 feb *", date(2021, 3, 1, 0, 0, 0), date(2024, 2, 29, 12, 0, 0)},
+		{"0 0 * jan-jun/2 *", date(2020, 2, 10, 0, 0, 0), date(2020, 3, 1, 0, 0, 0)},
+		{"@monthly", date(2020, 1, 31, 12, 0, 0), date(2020, 2, 1, 0, 0, 0)},
+		{"@hourly", date(2020, 1, 31, 23, 0, 0), date(2020, 2, 1, 0, 0, 0)},
+		{"@every 90s", date(2020, 1, 31, 23, 0, 10), date(2020, 1, 31, 23, 1, 40)},
+	} {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Unable to parse \"%s\": %v", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(test.from); !next.Equal(test.expected) {
+			t.Errorf("\"%s\" from %s: expected %s, got %s", test.spec, test.from, test.expected, next)
+		}
+	}
+}
+
+func TestNextWithoutActivation(t *testing.T) {
+	schedule, err := Parse("0 0 31 feb *")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+	if next := schedule.Next(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Fatalf("Expected no activation, got %s", next)
+	}
+}
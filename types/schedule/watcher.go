@@ -0,0 +1,104 @@
+package schedule
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
+
+// Polling files watcher, it reports changes (creation, update and removal) of a set of files, grouping changes
+// occurring within the debounce time
+type Watcher struct {
+	clock    Clock
+	interval time.Duration
+	debounce time.Duration
+	files    map[string]fileState
+}
+
+// Replaces the watched files, taking the current state of each file as reference
+func (watcher *Watcher) SetFiles(files []string) {
+	watcher.files = make(map[string]fileState)
+	for _, file := range files {
+		watcher.files[file] = statFile(file)
+	}
+}
+
+// Retrieves the watched files
+func (watcher *Watcher) GetFiles() []string {
+	var files []string = make([]string, 0)
+	for file, _ := range watcher.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (watcher *Watcher) poll(changed map[string]bool) bool {
+	var found bool = false
+	for file, state := range watcher.files {
+		current := statFile(file)
+		if current != state {
+			watcher.files[file] = current
+			changed[file] = true
+			found = true
+		}
+	}
+	return found
+}
+
+// Waits for changes in the watched files, and returns the sorted list of changed files when no further change happens
+// within the debounce time. It returns false if the stop channel is closed or receives a value
+func (watcher *Watcher) Watch(stop <-chan struct{}) ([]string, bool) {
+	var changed map[string]bool = make(map[string]bool)
+	var lastChange time.Time
+	for {
+		select {
+		case <-stop:
+			return nil, false
+		case <-watcher.clock.After(watcher.interval):
+		}
+		if watcher.poll(changed) {
+			lastChange = watcher.clock.Now()
+		}
+		if len(changed) > 0 && watcher.clock.Now().Sub(lastChange) >= watcher.debounce {
+			var files []string = make([]string, 0)
+			for file, _ := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			return files, true
+		}
+	}
+}
+
+// Creates a new files Watcher, polling files state at the given interval and grouping changes within the debounce time,
+// using the given Clock or the system clock if nil
+func NewWatcher(clock Clock, interval time.Duration, debounce time.Duration) *Watcher {
+	if clock == nil {
+		clock = NewSystemClock()
+	}
+	return &Watcher{
+		clock:    clock,
+		interval: interval,
+		debounce: debounce,
+		files:    make(map[string]fileState),
+	}
+}
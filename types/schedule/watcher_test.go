@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeWatchedFile(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Unable to write file: %v", err)
+	}
+}
+
+func TestWatcherDebouncesChanges(t *testing.T) {
+	var folder string = t.TempDir()
+	var feed string = filepath.Join(folder, "feed.yaml")
+	var hosts string = filepath.Join(folder, "hosts.yaml")
+	writeWatchedFile(t, feed, "name: feed")
+	writeWatchedFile(t, hosts, "groups: []")
+	var clock *fakeClock = newFakeClock(time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC))
+	var watcher *Watcher = NewWatcher(clock, time.Second, 5*time.Second)
+	watcher.SetFiles([]string{feed, hosts, filepath.Join(folder, "missing.yaml")})
+	var runs chan []string = make(chan []string, 10)
+	var stop chan struct{} = make(chan struct{})
+	var done chan struct{} = make(chan struct{})
+	// Continuous deploy loop: each returned change runs the deploy
+	go func() {
+		for {
+			changed, ok := watcher.Watch(stop)
+			if !ok {
+				break
+			}
+			runs <- changed
+		}
+		close(done)
+	}()
+	// Several modifications within the debounce time, the contents size changes at each write
+	for index, content := range []string{"name: feed-1", "name: feed-12", "name: feed-123"} {
+		if d := nextWait(t, clock); d != time.Second {
+			t.Fatalf("Expected to poll after 1s, got %s", d)
+		}
+		writeWatchedFile(t, feed, content)
+		if index == 1 {
+			writeWatchedFile(t, hosts, "groups: [web]")
+		}
+		clock.Advance(time.Second)
+	}
+	// No run until the debounce time elapses without changes
+	for index := 0; index < 4; index++ {
+		nextWait(t, clock)
+		if len(runs) > 0 {
+			t.Fatalf("Unexpected run before the debounce time: %v", <-runs)
+		}
+		clock.Advance(time.Second)
+	}
+	nextWait(t, clock)
+	clock.Advance(time.Second)
+	select {
+	case changed := <-runs:
+		if strings.Join(changed, ",") != feed+","+hosts {
+			t.Fatalf("Unexpected changed files: %v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watcher didn't report the changes")
+	}
+	// Without further changes the deploy doesn't run again
+	for index := 0; index < 10; index++ {
+		nextWait(t, clock)
+		clock.Advance(time.Second)
+	}
+	nextWait(t, clock)
+	if len(runs) > 0 {
+		t.Fatalf("Expected a single run, got also %v", <-runs)
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watcher didn't stop")
+	}
+}
+
+func TestWatcherReportsCreatedFiles(t *testing.T) {
+	var folder string = t.TempDir()
+	var created string = filepath.Join(folder, "created.yaml")
+	var clock *fakeClock = newFakeClock(time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC))
+	var watcher *Watcher = NewWatcher(clock, time.Second, 0)
+	watcher.SetFiles([]string{created})
+	if files := watcher.GetFiles(); len(files) != 1 || files[0] != created {
+		t.Fatalf("Unexpected watched files: %v", files)
+	}
+	var result chan []string = make(chan []string, 1)
+	go func() {
+		changed, _ := watcher.Watch(nil)
+		result <- changed
+	}()
+	nextWait(t, clock)
+	writeWatchedFile(t, created, "name: created")
+	clock.Advance(time.Second)
+	select {
+	case changed := <-result:
+		if len(changed) != 1 || changed[0] != created {
+			t.Fatalf("Unexpected changed files: %v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watcher didn't report the creation")
+	}
+}
@@ -23,3 +23,10 @@ type StepRunnable interface {
 	// Verify equality between StepRunnable instances
 	Equals(r StepRunnable) bool
 }
+
+// Step Runnable that supports the check mode, describing the changes it would apply without applying them
+type CheckableStepRunnable interface {
+	StepRunnable
+	// Describes the changes the step would apply on the host, an empty description means no change
+	Check() (string, error)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Resolves a file path relative to the given base folder, absolute paths (or empty ones) are returned as they are
+func ResolvePath(path string, baseDir string) string {
+	if path == "" || baseDir == "" {
+		return path
+	}
+	if strings.Index(path, ":") < 0 &&
+		strings.Index(path, "/") != 0 &&
+		strings.Index(path, "\\") != 0 {
+		return baseDir + string(os.PathSeparator) + path
+	}
+	return path
+}
+
+// Creates a new HTTP client honouring the given TLS settings:
+// ca certificate used to verify the server, client certificate / key pair and the insecure skip verify flag
+func NewHttpClient(caCert string, certificate string, keyFile string, insecure bool, timeout time.Duration) (*http.Client, error) {
+	var tlsConfig *tls.Config = &tls.Config{
+		InsecureSkipVerify: insecure,
+	}
+	if caCert != "" {
+		pem, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, errors.New("utils.NewHttpClient -> Unable to read ca certificate, cause: " + err.Error())
+		}
+		var pool *x509.CertPool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("utils.NewHttpClient -> Unable to parse ca certificate: " + caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certificate != "" && keyFile != "" {
+		pair, err := tls.LoadX509KeyPair(certificate, keyFile)
+		if err != nil {
+			return nil, errors.New("utils.NewHttpClient -> Unable to load client certificate, cause: " + err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"math"
+	"strings"
+)
+
+// Splits the group hosts in batches of the given size, or of the given percentage of the group hosts if size is 0.
+// All the hosts are in a single batch when both are 0
+func hostBatches(selectedHostGroup *defaults.HostGroups, size int, percentage float64) []*defaults.HostGroups {
+	var hostsCount int = len(selectedHostGroup.Hosts)
+	if size <= 0 && percentage > 0 {
+		size = int(math.Ceil(float64(hostsCount) * percentage / 100))
+	}
+	if size <= 0 || size >= hostsCount {
+		return []*defaults.HostGroups{selectedHostGroup}
+	}
+	var batches []*defaults.HostGroups = make([]*defaults.HostGroups, 0)
+	for start := 0; start < hostsCount; start += size {
+		var end int = start + size
+		if end > hostsCount {
+			end = hostsCount
+		}
+		var batch defaults.HostGroups = *selectedHostGroup
+		batch.Hosts = selectedHostGroup.Hosts[start:end]
+		batches = append(batches, &batch)
+	}
+	return batches
+}
+
+// Verifies if any host of a batch failed, failures of steps with ignored errors don't count
+func batchFailed(batch *defaults.HostGroups, handler *ErrorHandler) bool {
+	for _, host := range batch.Hosts {
+		if handler.IsFailed(inventory.SessionKey(batch, host)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostNames(group *defaults.HostGroups) string {
+	var names []string = make([]string, 0)
+	for _, host := range group.Hosts {
+		names = append(names, host.Name)
+	}
+	return strings.Join(names, ", ")
+}
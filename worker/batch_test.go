@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"testing"
+)
+
+func TestHostBatches(t *testing.T) {
+	var group defaults.HostGroups = testGroup("web", "web-01", "web-02", "web-03", "web-04", "web-05")
+	for _, test := range []struct {
+		size       int
+		percentage float64
+		expected   []string
+	}{
+		{0, 0, []string{"web-01, web-02, web-03, web-04, web-05"}},
+		{2, 0, []string{"web-01, web-02", "web-03, web-04", "web-05"}},
+		{5, 0, []string{"web-01, web-02, web-03, web-04, web-05"}},
+		{10, 0, []string{"web-01, web-02, web-03, web-04, web-05"}},
+		// Percentages are rounded up to the next host
+		{0, 25, []string{"web-01, web-02", "web-03, web-04", "web-05"}},
+		{0, 10, []string{"web-01", "web-02", "web-03", "web-04", "web-05"}},
+		{0, 100, []string{"web-01, web-02, web-03, web-04, web-05"}},
+		// The count is preferred to the percentage
+		{3, 20, []string{"web-01, web-02, web-03", "web-04, web-05"}},
+	} {
+		var batches []*defaults.HostGroups = hostBatches(&group, test.size, test.percentage)
+		var names []string = make([]string, 0)
+		for _, batch := range batches {
+			if batch.Name != group.Name {
+				t.Errorf("Batch of %v/%v%%: expected group name %s, got %s", test.size, test.percentage, group.Name, batch.Name)
+			}
+			names = append(names, hostNames(batch))
+		}
+		if len(names) != len(test.expected) {
+			t.Errorf("Batches of %v/%v%%: expected %q, got %q", test.size, test.percentage, test.expected, names)
+			continue
+		}
+		for index := range names {
+			if names[index] != test.expected[index] {
+				t.Errorf("Batches of %v/%v%%: expected %q, got %q", test.size, test.percentage, test.expected, names)
+				break
+			}
+		}
+	}
+}
+
+func TestSerialRolloutStopsAfterBatchWithFailedHost(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02", "web-03", "web-04")}
+	var deployRuns *testRuns = newTestRuns()
+	var healthRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:        "rollout",
+		HostGroup:   "web",
+		SerialCount: 2,
+		Steps: []*module.Step{
+			{Name: "deploy", StepData: newTestRunnable(deployRuns, nil)},
+			// Health gate failing on a single host of the first batch
+			{Name: "health", StepData: newTestRunnable(healthRuns, failOn("web-02"))},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Hosts of batch 1/2 failed")
+	if deployRuns.Count("web-01") != 1 || deployRuns.Count("web-02") != 1 {
+		t.Fatalf("Expected the first batch deployed, got %s", deployRuns)
+	}
+	if deployRuns.Count("web-03") != 0 || deployRuns.Count("web-04") != 0 {
+		t.Fatalf("Expected the second batch not deployed, got %s", deployRuns)
+	}
+}
+
+func TestSerialRolloutIgnoresIgnoredErrors(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02", "web-03", "web-04")}
+	var runs *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:             "rollout",
+		HostGroup:        "web",
+		SerialPercentage: 50,
+		Steps: []*module.Step{
+			{Name: "optional", StepData: newTestRunnable(runs, failOn("web-01")), IgnoreErrors: true},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "")
+	if runs.Count("web-03") != 1 || runs.Count("web-04") != 1 {
+		t.Fatalf("Expected the second batch executed, got %s", runs)
+	}
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"strings"
+)
+
+// Runs the feeds in check mode: hosts are not connected and steps describe the changes they would apply, via the
+// threads.CheckableStepRunnable interface, without applying them
+var CheckMode bool = false
+
+const (
+	// Check status of steps that would apply changes
+	CHECK_CHANGE string = "change"
+	// Check status of steps that would not apply changes
+	CHECK_NO_CHANGE string = "no change"
+	// Check status of steps whose module doesn't support the check mode
+	CHECK_UNKNOWN string = "unknown"
+)
+
+// Checks a step on the selected group hosts, limited to the given hosts session keys if not nil, reporting and
+// recording in the feed plan the per host outcome
+func checkStepThreads(step *module.Step, thread threads.StepRunnable, hostThreads map[string]threads.StepRunnable,
+	selectedHostGroup *defaults.HostGroups, hostsMap map[string]bool, errorsHandler *ErrorHandler,
+	config defaults.ConfigPattern, sessionsMap map[string]module.Session, logger log.Logger) []error {
+	var errorsList []error = make([]error, 0)
+	var stepName string = step.Name
+	if stepName == "" {
+		stepName = "<none>"
+	}
+	hosts, pending, skippedMap := selectStepHosts(step, selectedHostGroup, hostsMap, errorsHandler, sessionsMap, logger)
+	var pendingMap map[string]bool = make(map[string]bool)
+	for _, host := range pending {
+		pendingMap[host.Name] = true
+	}
+	for _, host := range hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		if errS, ok := skippedMap[sessMapId]; ok {
+			logSkipped(logger, host.Name, step.When, errS, step.IgnoreErrors)
+			if errS != nil && !step.IgnoreErrors {
+				errorsHandler.SetFailed(sessMapId, errS)
+			}
+			errorsHandler.AddPlan(sessMapId, fmt.Sprintf("%s: skipped", stepName))
+			continue
+		}
+		if !pendingMap[host.Name] {
+			continue
+		}
+		hostThread := hostStepThread(thread, hostThreads, sessMapId).Clone()
+		if session, ok := sessionsMap[sessMapId]; ok {
+			hostThread.SetSession(session)
+		}
+		hostThread.SetConfig(config)
+		hostThread.SetHost(host)
+		checkable, ok := hostThread.(threads.CheckableStepRunnable)
+		if !ok {
+			logger.Warnf("- [Host: %s, check: %s]\n Module %s doesn't support check mode", host.Name, CHECK_UNKNOWN, step.StepType)
+			errorsHandler.AddPlan(sessMapId, fmt.Sprintf("%s: %s", stepName, CHECK_UNKNOWN))
+			continue
+		}
+		plan, err := checkable.Check()
+		if err != nil {
+			if step.IgnoreErrors {
+				logger.Warnf("- [Host: %s, check: ko, ignored]\n Error: %s", host.Name, err.Error())
+			} else {
+				logger.Failuref("- [Host: %s, check: ko]\n Error: %s", host.Name, err.Error())
+				errorsHandler.SetFailed(sessMapId, err)
+			}
+			errorsHandler.AddPlan(sessMapId, fmt.Sprintf("%s: ko -> %s", stepName, err.Error()))
+		} else if strings.TrimSpace(plan) == "" {
+			logger.Successf("- [Host: %s, check: %s]", host.Name, CHECK_NO_CHANGE)
+			errorsHandler.AddPlan(sessMapId, fmt.Sprintf("%s: %s", stepName, CHECK_NO_CHANGE))
+		} else {
+			logger.Warnf("- [Host: %s, check: %s]\n %s", host.Name, CHECK_CHANGE, plan)
+			errorsHandler.AddPlan(sessMapId, fmt.Sprintf("%s: %s -> %s", stepName, CHECK_CHANGE, plan))
+		}
+	}
+	return errorsList
+}
+
+// Records a line of the check plan of a host, identified by the session key
+func (handler *ErrorHandler) AddPlan(sessMapId string, line string) {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.plan == nil {
+		handler.plan = make(map[string][]string)
+	}
+	handler.plan[sessMapId] = append(handler.plan[sessMapId], line)
+}
+
+// Reports the check plan of each host of the group
+func reportCheckPlan(feedName string, selectedHostGroup *defaults.HostGroups, handler *ErrorHandler, logger log.Logger) {
+	handler.Lock()
+	defer handler.Unlock()
+	logger.Warnf("Feed %s check plan:", feedName)
+	for _, host := range selectedHostGroup.Hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		logger.Warnf("- Host: %s", host.Name)
+		if len(handler.plan[sessMapId]) == 0 {
+			logger.Warn("  nothing to do")
+		}
+		for _, line := range handler.plan[sessMapId] {
+			logger.Warnf("  - %s", line)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"errors"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"strings"
+	"testing"
+)
+
+// Step runnable supporting the check mode, the check function describes the changes on a host
+type checkableTestRunnable struct {
+	*testRunnable
+	check func(host string) (string, error)
+}
+
+func (runnable *checkableTestRunnable) Check() (string, error) {
+	return runnable.check(runnable.host.Name)
+}
+
+func (runnable *checkableTestRunnable) Clone() threads.StepRunnable {
+	return &checkableTestRunnable{
+		testRunnable: runnable.testRunnable.Clone().(*testRunnable),
+		check:        runnable.check,
+	}
+}
+
+func setCheckMode(t *testing.T) {
+	var checkMode bool = CheckMode
+	CheckMode = true
+	t.Cleanup(func() {
+		CheckMode = checkMode
+	})
+}
+
+func TestCheckStepPlan(t *testing.T) {
+	var group defaults.HostGroups = testGroup("web", "web-01", "web-02")
+	var sessionsMap map[string]module.Session = testSessions([]defaults.HostGroups{group})
+	var handler *ErrorHandler = &ErrorHandler{
+		errorList:   make([]ErrorItem, 0),
+		attempt:     1,
+		failedHosts: make(map[string]error),
+	}
+	var runs *testRuns = newTestRuns()
+	var checkable *checkableTestRunnable = &checkableTestRunnable{
+		testRunnable: newTestRunnable(runs, nil),
+		check: func(host string) (string, error) {
+			if host == "web-01" {
+				return "update /etc/app.conf", nil
+			}
+			return "", nil
+		},
+	}
+	var logger log.Logger = log.NewLogger("test", log.ERROR)
+	for _, step := range []*module.Step{
+		{Name: "configure", StepType: "test", StepData: checkable},
+		// The module doesn't implement Check()
+		{Name: "restart", StepType: "service", StepData: newTestRunnable(runs, nil)},
+		{Name: "verify", StepType: "test", StepData: &checkableTestRunnable{
+			testRunnable: newTestRunnable(runs, nil),
+			check:        func(host string) (string, error) { return "", errors.New("config not found") },
+		}, IgnoreErrors: true},
+	} {
+		var thread threads.StepRunnable = step.StepData.(threads.StepRunnable)
+		if errs := checkStepThreads(step, thread, nil, &group, nil, handler, defaults.ConfigPattern{}, sessionsMap, logger); len(errs) > 0 {
+			t.Fatalf("Unable to check step %s: %v", step.Name, errs)
+		}
+	}
+	for host, expected := range map[string]string{
+		"web-01": "configure: change -> update /etc/app.conf|restart: unknown|verify: ko -> config not found",
+		"web-02": "configure: no change|restart: unknown|verify: ko -> config not found",
+	} {
+		var sessMapId string = inventory.SessionKey(&group, defaults.HostValue{Name: host})
+		if plan := strings.Join(handler.plan[sessMapId], "|"); plan != expected {
+			t.Errorf("Host %s: expected plan %q, got %q", host, expected, plan)
+		}
+		if handler.IsFailed(sessMapId) {
+			t.Errorf("Host %s: unexpected failure", host)
+		}
+	}
+	if runs.String() != "" {
+		t.Fatalf("Expected no step run in check mode, got %s", runs)
+	}
+}
+
+func TestCheckModeDoesntRunSteps(t *testing.T) {
+	setCheckMode(t)
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02")}
+	var runs *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{Name: "restart", StepType: "service", StepData: newTestRunnable(runs, failOn("web-01"))},
+			{Name: "configure", StepType: "test", StepData: &checkableTestRunnable{
+				testRunnable: newTestRunnable(runs, nil),
+				check: func(host string) (string, error) {
+					if host == "web-02" {
+						return "", errors.New("config not found")
+					}
+					return "", nil
+				},
+			}},
+		},
+	}
+	// Unknown check outcomes don't fail hosts, check errors do
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release: 1 host(s) failed")
+	if runs.String() != "" {
+		t.Fatalf("Expected no step run in check mode, got %s", runs)
+	}
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/net/local"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Step results, shared among the step runnable clones
+type localStepResults struct {
+	sync.Mutex
+	outputs []string
+}
+
+// Step runnable executing a command and a file transfer with the host client
+type localStepRunnable struct {
+	uuid    string
+	client  generic.NetworkClient
+	host    defaults.HostValue
+	command string
+	source  string
+	target  string
+	results *localStepResults
+}
+
+var localStepCount int = 0
+
+func (runnable *localStepRunnable) Run() error {
+	output, err := runnable.client.NewCmd(runnable.command).ExecuteWithOutput()
+	if err != nil {
+		return err
+	}
+	runnable.results.Lock()
+	runnable.results.outputs = append(runnable.results.outputs, string(output))
+	runnable.results.Unlock()
+	return runnable.client.FileTranfer().TransferFile(runnable.source, runnable.target)
+}
+
+func (runnable *localStepRunnable) Stop() error     { return nil }
+func (runnable *localStepRunnable) Kill() error     { return nil }
+func (runnable *localStepRunnable) Pause() error    { return nil }
+func (runnable *localStepRunnable) Resume() error   { return nil }
+func (runnable *localStepRunnable) IsRunning() bool { return false }
+func (runnable *localStepRunnable) IsPaused() bool  { return false }
+func (runnable *localStepRunnable) UUID() string    { return runnable.uuid }
+
+func (runnable *localStepRunnable) Clone() threads.StepRunnable {
+	localStepCount++
+	return &localStepRunnable{
+		uuid:    fmt.Sprintf("local-step-%v", localStepCount),
+		command: runnable.command,
+		source:  runnable.source,
+		target:  runnable.target,
+		results: runnable.results,
+	}
+}
+
+func (runnable *localStepRunnable) SetClient(client generic.NetworkClient)  { runnable.client = client }
+func (runnable *localStepRunnable) SetHost(host defaults.HostValue)         { runnable.host = host }
+func (runnable *localStepRunnable) SetSession(session module.Session)       {}
+func (runnable *localStepRunnable) SetConfig(config defaults.ConfigPattern) {}
+
+func (runnable *localStepRunnable) Equals(r threads.StepRunnable) bool {
+	return r != nil && r.UUID() == runnable.uuid
+}
+
+func TestExecuteFeedOnLocalHost(t *testing.T) {
+	var folder string = t.TempDir()
+	var source string = filepath.Join(folder, "app.conf")
+	if err := ioutil.WriteFile(source, []byte("listen: 8080"), 0644); err != nil {
+		t.Fatalf("Unable to write source file: %v", err)
+	}
+	var target string = filepath.Join(folder, "deployed.conf")
+	var results *localStepResults = &localStepResults{outputs: make([]string, 0)}
+	var netConfig *module.NetProtocolType = &module.NetProtocolType{NetProtocol: module.NET_PROTOCOL_LOCAL}
+	var group defaults.HostGroups = defaults.HostGroups{
+		Name:  "local",
+		Hosts: []defaults.HostValue{{Name: "localhost", IpAddress: "127.0.0.1"}},
+	}
+	var config defaults.ConfigPattern = defaults.ConfigPattern{
+		Config:     &module.DeployConfig{MaxThreads: 1},
+		Net:        netConfig,
+		HostGroups: []defaults.HostGroups{group},
+	}
+	handler, handlerConfig := local.NewConnectionHandler(false, false)
+	var session module.Session = module.NewSession(module.NewSessionId())
+	session.SetSystemObject("connection-handler", handler)
+	session.SetSystemObject("runtime-net", netConfig)
+	var sessionsMap map[string]module.Session = map[string]module.Session{
+		inventory.SessionKey(&group, group.Hosts[0]): session,
+	}
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "local",
+		HostGroup: "local",
+		Steps: []*module.Step{
+			{
+				Name:     "deploy",
+				StepType: "test",
+				StepData: &localStepRunnable{
+					command: "echo deployed",
+					source:  source,
+					target:  target,
+					results: results,
+				},
+			},
+		},
+	}
+	var connectionConfig module.ConnectionConfig = module.ConnectionConfig{
+		UseUserPassword: handlerConfig.UseUserPassword,
+	}
+	if errs := ExecuteFeed(connectionConfig, config, feed, sessionsMap, log.NewLogger("test", log.ERROR)); len(errs) > 0 {
+		t.Fatalf("Unable to execute feed: %v", errs)
+	}
+	if len(results.outputs) != 1 || results.outputs[0] != "deployed\n" {
+		t.Fatalf("Unexpected command outputs: %q", results.outputs)
+	}
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Unable to read transferred file: %v", err)
+	}
+	if string(data) != "listen: 8080" {
+		t.Fatalf("Unexpected transferred content: %q", string(data))
+	}
+}
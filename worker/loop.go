@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Session variable that contains the current loop item
+	LOOP_ITEM_VAR string = "item"
+	// Session variable that contains the current loop item index, starting from 0
+	LOOP_INDEX_VAR string = "index"
+)
+
+// Placeholders of the loop item and index in the step module arguments, e.g.: {{ item }}
+var loopPlaceholderRegexp *regexp.Regexp = regexp.MustCompile(`\{\{\s*(item|index)\s*\}\}`)
+
+// Retrieves the loop items of a step for each host session key, reading the loop variable from the host session when
+// the step doesn't declare a list of items
+func loopItems(step *module.Step, selectedHostGroup *defaults.HostGroups, sessionsMap map[string]module.Session, logger log.Logger) map[string][]string {
+	var itemsMap map[string][]string = make(map[string][]string)
+	for _, host := range selectedHostGroup.Hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		session, ok := sessionsMap[sessMapId]
+		if !ok {
+			continue
+		}
+		if step.LoopVar == "" {
+			itemsMap[sessMapId] = step.Loop
+			continue
+		}
+		value, err := session.GetVar(step.LoopVar)
+		if err != nil {
+			logger.Warnf("Loop variable %s not found for host: %s", step.LoopVar, host.Name)
+			itemsMap[sessMapId] = make([]string, 0)
+			continue
+		}
+		itemsMap[sessMapId] = parseItems(value)
+	}
+	return itemsMap
+}
+
+// Parses a variable value as list of items, the value can be a list literal (e.g.: [a, b]) or a list of lines
+func parseItems(value string) []string {
+	var items []string = make([]string, 0)
+	var text string = strings.TrimSpace(value)
+	if strings.HasPrefix(text, "[") {
+		var list []interface{} = make([]interface{}, 0)
+		if err := yaml.Unmarshal([]byte(text), &list); err == nil {
+			for _, item := range list {
+				items = append(items, fmt.Sprintf("%v", item))
+			}
+			return items
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// Verifies if the step module arguments contain the {{ item }} or {{ index }} placeholders
+func hasLoopPlaceholders(data interface{}) bool {
+	switch value := data.(type) {
+	case string:
+		return loopPlaceholderRegexp.MatchString(value)
+	case []string:
+		for _, element := range value {
+			if loopPlaceholderRegexp.MatchString(element) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, element := range value {
+			if hasLoopPlaceholders(element) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, element := range value {
+			if hasLoopPlaceholders(element) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, element := range value {
+			if hasLoopPlaceholders(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Retrieves a copy of the step module arguments, with the {{ item }} and {{ index }} placeholders replaced by the
+// given loop item and index
+func replaceLoopPlaceholders(data interface{}, item string, index int) interface{} {
+	var replace = func(text string) string {
+		return loopPlaceholderRegexp.ReplaceAllStringFunc(text, func(placeholder string) string {
+			if loopPlaceholderRegexp.FindStringSubmatch(placeholder)[1] == LOOP_INDEX_VAR {
+				return fmt.Sprintf("%v", index)
+			}
+			return item
+		})
+	}
+	switch value := data.(type) {
+	case string:
+		return replace(value)
+	case []string:
+		var out []string = make([]string, 0)
+		for _, element := range value {
+			out = append(out, replace(element))
+		}
+		return out
+	case []interface{}:
+		var out []interface{} = make([]interface{}, 0)
+		for _, element := range value {
+			out = append(out, replaceLoopPlaceholders(element, item, index))
+		}
+		return out
+	case map[string]interface{}:
+		var out map[string]interface{} = make(map[string]interface{})
+		for key, element := range value {
+			out[key] = replaceLoopPlaceholders(element, item, index)
+		}
+		return out
+	case map[interface{}]interface{}:
+		var out map[interface{}]interface{} = make(map[interface{}]interface{})
+		for key, element := range value {
+			out[key] = replaceLoopPlaceholders(element, item, index)
+		}
+		return out
+	}
+	return data
+}
+
+// Removes the loop item and index variables from the host sessions
+func clearLoopVars(itemsMap map[string][]string, sessionsMap map[string]module.Session) {
+	for sessMapId, _ := range itemsMap {
+		if session, ok := sessionsMap[sessMapId]; ok {
+			session.RemoveVar(LOOP_ITEM_VAR)
+			session.RemoveVar(LOOP_INDEX_VAR)
+		}
+	}
+}
+
+// Retrieves the step thread of a host, the one converted for the host loop item when available
+func hostStepThread(thread threads.StepRunnable, hostThreads map[string]threads.StepRunnable, sessMapId string) threads.StepRunnable {
+	if hostThread, ok := hostThreads[sessMapId]; ok {
+		return hostThread
+	}
+	return thread
+}
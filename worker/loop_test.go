@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplaceLoopPlaceholders(t *testing.T) {
+	var data map[interface{}]interface{} = map[interface{}]interface{}{
+		"exec":   "apt-get install -y {{ item }}",
+		"args":   []interface{}{"--index={{index}}", 3},
+		"nested": map[string]interface{}{"path": "/tmp/{{  item }}-{{ index }}.log", "{{ item }}": "key"},
+	}
+	if !hasLoopPlaceholders(data) {
+		t.Fatal("Expected loop placeholders in the module arguments")
+	}
+	var expected map[interface{}]interface{} = map[interface{}]interface{}{
+		"exec":   "apt-get install -y nginx",
+		"args":   []interface{}{"--index=1", 3},
+		"nested": map[string]interface{}{"path": "/tmp/nginx-1.log", "{{ item }}": "key"},
+	}
+	if replaced := replaceLoopPlaceholders(data, "nginx", 1); !reflect.DeepEqual(replaced, expected) {
+		t.Fatalf("Expected %v, got %v", expected, replaced)
+	}
+	if data["exec"] != "apt-get install -y {{ item }}" {
+		t.Fatalf("Original module arguments changed: %v", data)
+	}
+	if hasLoopPlaceholders(map[string]interface{}{"exec": "echo {{ other }}", "items": []string{"item"}}) {
+		t.Fatal("Unexpected loop placeholders in the module arguments")
+	}
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-tcp-common/log"
+)
+
+// Feed failures policy
+type FailurePolicy struct {
+	// Aborts the feed at the end of the first step with a failed host
+	FailFast bool
+	// Aborts the feed at the end of the step where the failed hosts percentage exceeds this value, 0 disables it
+	MaxFailPercentage float64
+	// Number of hosts in the feed group
+	HostsCount int
+}
+
+// Verifies if a host, identified by the session key, failed a previous step of the feed
+func (handler *ErrorHandler) IsFailed(sessMapId string) bool {
+	handler.Lock()
+	defer handler.Unlock()
+	_, ok := handler.failedHosts[sessMapId]
+	return ok
+}
+
+// Marks a host, identified by the session key, as failed: it's excluded from the next steps of the feed
+func (handler *ErrorHandler) SetFailed(sessMapId string, err error) {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.failedHosts == nil {
+		handler.failedHosts = make(map[string]error)
+	}
+	if _, ok := handler.failedHosts[sessMapId]; !ok {
+		handler.failedHosts[sessMapId] = err
+	}
+}
+
+// Aborts the feed execution for the given reason
+func (handler *ErrorHandler) Abort(reason string) {
+	handler.Lock()
+	defer handler.Unlock()
+	if handler.aborted == "" {
+		handler.aborted = reason
+	}
+}
+
+// Retrieves the reason of the feed abort, if aborted
+func (handler *ErrorHandler) Aborted() (string, bool) {
+	handler.Lock()
+	defer handler.Unlock()
+	return handler.aborted, handler.aborted != ""
+}
+
+// Verifies the failures policy against the failed hosts, aborting the feed when the policy is violated
+func (handler *ErrorHandler) CheckPolicy() (string, bool) {
+	handler.Lock()
+	var failed int = len(handler.failedHosts)
+	var policy FailurePolicy = handler.policy
+	handler.Unlock()
+	if failed > 0 && policy.FailFast {
+		handler.Abort(fmt.Sprintf("Fail fast policy, %v host(s) failed", failed))
+	} else if failed > 0 && policy.MaxFailPercentage > 0 && policy.HostsCount > 0 {
+		var percentage float64 = float64(failed) * 100 / float64(policy.HostsCount)
+		if percentage > policy.MaxFailPercentage {
+			handler.Abort(fmt.Sprintf("Failed hosts %.1f%% (%v/%v) exceed max fail percentage %v%%", percentage, failed, policy.HostsCount, policy.MaxFailPercentage))
+		}
+	}
+	return handler.Aborted()
+}
+
+// Reports the feed outcome per host, and retrieves the errors for failed hosts and feed abort. Hosts not executed
+// because of the feed abort are reported apart
+func reportFeedSummary(feedName string, selectedHostGroup *defaults.HostGroups, notExecuted int, handler *ErrorHandler, logger log.Logger) []error {
+	var errorsList []error = make([]error, 0)
+	handler.Lock()
+	var failedHosts map[string]error = make(map[string]error)
+	for key, err := range handler.failedHosts {
+		failedHosts[key] = err
+	}
+	var aborted string = handler.aborted
+	handler.Unlock()
+	var hostsCount int = len(selectedHostGroup.Hosts)
+	var summary string = fmt.Sprintf("Feed %s summary: %v host(s), ok: %v, failed: %v", feedName, hostsCount, hostsCount-len(failedHosts)-notExecuted, len(failedHosts))
+	if notExecuted > 0 {
+		summary += fmt.Sprintf(", not executed: %v", notExecuted)
+	}
+	if aborted != "" {
+		summary += ", aborted: " + aborted
+	}
+	if len(failedHosts) == 0 && aborted == "" {
+		logger.Successf("%s", summary)
+		return errorsList
+	}
+	logger.Failuref("%s", summary)
+	for _, host := range selectedHostGroup.Hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		if err, ok := failedHosts[sessMapId]; ok {
+			logger.Failuref("- [Host: %s, status: failed]\n Error: %v", host.Name, err)
+		}
+	}
+	if aborted != "" {
+		errorsList = append(errorsList, errors.New(fmt.Sprintf("Feed %s aborted: %s", feedName, aborted)))
+	} else {
+		errorsList = append(errorsList, errors.New(fmt.Sprintf("Feed %s: %v host(s) failed", feedName, len(failedHosts))))
+	}
+	return errorsList
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"testing"
+)
+
+func TestFailedHostsSkipNextSteps(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02", "web-03")}
+	var nextRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{Name: "install", StepData: newTestRunnable(newTestRuns(), failOn("web-02"))},
+			{Name: "start", StepData: newTestRunnable(nextRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release: 1 host(s) failed")
+	if nextRuns.Count("web-01") != 1 || nextRuns.Count("web-02") != 0 || nextRuns.Count("web-03") != 1 {
+		t.Fatalf("Expected the next step on the hosts not failed, got %s", nextRuns)
+	}
+}
+
+func TestFailFastAbortsAfterFailedStep(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02", "web-03")}
+	var installRuns *testRuns = newTestRuns()
+	var nextRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		FailFast:  true,
+		Steps: []*module.Step{
+			{Name: "install", StepData: newTestRunnable(installRuns, failOn("web-02"))},
+			{Name: "start", StepData: newTestRunnable(nextRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release aborted: Fail fast policy, 1 host(s) failed")
+	// The failing step completes on all hosts, the feed stops at its end
+	for _, host := range []string{"web-01", "web-02", "web-03"} {
+		if installRuns.Count(host) != 1 {
+			t.Fatalf("Expected the failing step on all hosts, got %s", installRuns)
+		}
+	}
+	if nextRuns.String() != "" {
+		t.Fatalf("Expected no step after the abort, got %s", nextRuns)
+	}
+}
+
+func TestMaxFailPercentageAbortsWhenExceeded(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02", "web-03", "web-04")}
+	var secondRuns *testRuns = newTestRuns()
+	var thirdRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:              "release",
+		HostGroup:         "web",
+		MaxFailPercentage: 30,
+		Steps: []*module.Step{
+			// 25% of failed hosts is within the limit
+			{Name: "install", StepData: newTestRunnable(newTestRuns(), failOn("web-01"))},
+			// 50% of failed hosts exceeds the limit
+			{Name: "configure", StepData: newTestRunnable(secondRuns, failOn("web-02"))},
+			{Name: "start", StepData: newTestRunnable(thirdRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Failed hosts 50.0% (2/4) exceed max fail percentage 30%")
+	if secondRuns.Count("web-01") != 0 || secondRuns.Count("web-03") != 1 || secondRuns.Count("web-04") != 1 {
+		t.Fatalf("Expected the second step on the hosts not failed, got %s", secondRuns)
+	}
+	if thirdRuns.String() != "" {
+		t.Fatalf("Expected no step after the abort, got %s", thirdRuns)
+	}
+}
+
+func TestIgnoredErrorsDontFailHosts(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02")}
+	var nextRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:              "release",
+		HostGroup:         "web",
+		FailFast:          true,
+		MaxFailPercentage: 10,
+		Steps: []*module.Step{
+			{Name: "cleanup", StepData: newTestRunnable(newTestRuns(), failOn("web-01", "web-02")), IgnoreErrors: true},
+			{Name: "start", StepData: newTestRunnable(nextRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "")
+	if nextRuns.Count("web-01") != 1 || nextRuns.Count("web-02") != 1 {
+		t.Fatalf("Expected the next step on all hosts, got %s", nextRuns)
+	}
+}
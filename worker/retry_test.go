@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fails the first runs on a host, up to the given count
+func failUntil(host string, count int) func(host string, session module.Session, count int) error {
+	return func(name string, session module.Session, run int) error {
+		if name == host && run <= count {
+			return fmt.Errorf("Run %v failed on %s", run, name)
+		}
+		return nil
+	}
+}
+
+func TestRetriesRunFailingHostsAgain(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02")}
+	var runs *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{Name: "download", StepData: newTestRunnable(runs, failUntil("web-01", 2)), Retries: 2},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "")
+	if runs.Count("web-01") != 3 || runs.Count("web-02") != 1 {
+		t.Fatalf("Expected 3 runs on web-01 and 1 on web-02, got %s", runs)
+	}
+}
+
+func TestRetriesExhaustedFailHost(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02")}
+	var runs *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{Name: "download", StepData: newTestRunnable(runs, failOn("web-01")), Retries: 2},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release: 1 host(s) failed")
+	if runs.Count("web-01") != 3 || runs.Count("web-02") != 1 {
+		t.Fatalf("Expected 3 runs on web-01 and 1 on web-02, got %s", runs)
+	}
+}
+
+func TestRetriesWaitWithBackoff(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01")}
+	var mutex sync.Mutex
+	var times []time.Time = make([]time.Time, 0)
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{
+				Name: "download",
+				StepData: newTestRunnable(newTestRuns(), func(host string, session module.Session, count int) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					times = append(times, time.Now())
+					return fmt.Errorf("Run %v failed", count)
+				}),
+				Retries: 3,
+				Delay:   20 * time.Millisecond,
+				Backoff: 2,
+			},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release: 1 host(s) failed")
+	if len(times) != 4 {
+		t.Fatalf("Expected 4 runs, got %v", len(times))
+	}
+	// Each wait doubles the previous one
+	for index, expected := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond} {
+		if elapsed := times[index+1].Sub(times[index]); elapsed < expected {
+			t.Fatalf("Expected retry %v after at least %s, got %s", index+1, expected, elapsed)
+		}
+	}
+}
+
+func TestUntilRetriesUntilConditionIsMet(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01", "web-02")}
+	var runs *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{
+				Name: "wait-ready",
+				StepData: newTestRunnable(runs, func(host string, session module.Session, count int) error {
+					// web-01 becomes ready at the third run, web-02 at the first one
+					if host == "web-02" || count >= 3 {
+						session.SetVar("status", "ready")
+					}
+					return nil
+				}),
+				Retries: 5,
+				Until:   `status == "ready"`,
+			},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "")
+	if runs.Count("web-01") != 3 || runs.Count("web-02") != 1 {
+		t.Fatalf("Expected 3 runs on web-01 and 1 on web-02, got %s", runs)
+	}
+}
+
+func TestUntilNotMetFailsHost(t *testing.T) {
+	var groups []defaults.HostGroups = []defaults.HostGroups{testGroup("web", "web-01")}
+	var runs *testRuns = newTestRuns()
+	var nextRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "release",
+		HostGroup: "web",
+		Steps: []*module.Step{
+			{Name: "wait-ready", StepData: newTestRunnable(runs, nil), Retries: 1, Until: `status == "ready"`},
+			{Name: "start", StepData: newTestRunnable(nextRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, groups, feed, nil), "Feed release: 1 host(s) failed")
+	if runs.Count("web-01") != 2 || nextRuns.String() != "" {
+		t.Fatalf("Expected 2 runs and no next step, got %s and %s", runs, nextRuns)
+	}
+}
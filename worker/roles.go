@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/roles"
+)
+
+// Retrieves a copy of the host group with the hosts matching the roles selector, the host group itself when the
+// selector is empty
+func roleHosts(selectedHostGroup *defaults.HostGroups, selectorText string) (*defaults.HostGroups, error) {
+	if selectorText == "" {
+		return selectedHostGroup, nil
+	}
+	selector, err := roles.Parse(selectorText)
+	if err != nil {
+		return nil, err
+	}
+	var group defaults.HostGroups = *selectedHostGroup
+	group.Hosts = make([]defaults.HostValue, 0)
+	for _, host := range selectedHostGroup.Hosts {
+		if selector.Matches(host.Roles) {
+			group.Hosts = append(group.Hosts, host)
+		}
+	}
+	return &group, nil
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/module"
+	"testing"
+)
+
+func TestStepRolesSkipNonMatchingHosts(t *testing.T) {
+	var group defaults.HostGroups = testGroup("fleet", "web-01", "web-02", "db-01", "bastion")
+	group.Hosts[0].Roles = []string{"web"}
+	group.Hosts[1].Roles = []string{"web", "canary"}
+	group.Hosts[2].Roles = []string{"db"}
+	var deployRuns *testRuns = newTestRuns()
+	var migrateRuns *testRuns = newTestRuns()
+	var commonRuns *testRuns = newTestRuns()
+	var feed *module.FeedExec = &module.FeedExec{
+		Name:      "fleet",
+		HostGroup: "fleet",
+		Steps: []*module.Step{
+			{Name: "deploy", Roles: "web & !canary", StepData: newTestRunnable(deployRuns, nil)},
+			{Name: "migrate", Roles: "db", StepData: newTestRunnable(migrateRuns, nil)},
+			{Name: "common", StepData: newTestRunnable(commonRuns, nil)},
+		},
+	}
+	expectErrors(t, executeTestFeed(t, []defaults.HostGroups{group}, feed, nil), "")
+	if deployRuns.String() != "web-01" {
+		t.Fatalf("Expected deploy on web-01 only, got %s", deployRuns)
+	}
+	if migrateRuns.String() != "db-01" {
+		t.Fatalf("Expected migrate on db-01 only, got %s", migrateRuns)
+	}
+	for _, host := range []string{"web-01", "web-02", "db-01", "bastion"} {
+		if commonRuns.Count(host) != 1 {
+			t.Fatalf("Expected the step without roles on all hosts, got %s", commonRuns)
+		}
+	}
+}
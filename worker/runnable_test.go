@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/net/local"
+	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/module"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"github.com/hellgate75/go-tcp-common/log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Runs of the test steps, shared among the step runnable clones
+type testRuns struct {
+	sync.Mutex
+	hosts  []string
+	counts map[string]int
+}
+
+func newTestRuns() *testRuns {
+	return &testRuns{
+		hosts:  make([]string, 0),
+		counts: make(map[string]int),
+	}
+}
+
+// Retrieves the hosts of the recorded runs, in runs order
+func (runs *testRuns) String() string {
+	runs.Lock()
+	defer runs.Unlock()
+	return strings.Join(runs.hosts, ",")
+}
+
+// Retrieves the number of runs on a host
+func (runs *testRuns) Count(host string) int {
+	runs.Lock()
+	defer runs.Unlock()
+	return runs.counts[host]
+}
+
+var testRunnableCount int = 0
+var testRunnableMutex sync.Mutex
+
+func nextTestRunnableId() string {
+	testRunnableMutex.Lock()
+	defer testRunnableMutex.Unlock()
+	testRunnableCount++
+	return fmt.Sprintf("test-step-%v", testRunnableCount)
+}
+
+// Step runnable recording its runs, the run function decides the outcome of each run on a host
+type testRunnable struct {
+	uuid    string
+	host    defaults.HostValue
+	session module.Session
+	runs    *testRuns
+	run     func(host string, session module.Session, count int) error
+}
+
+func newTestRunnable(runs *testRuns, run func(host string, session module.Session, count int) error) *testRunnable {
+	return &testRunnable{
+		uuid: nextTestRunnableId(),
+		runs: runs,
+		run:  run,
+	}
+}
+
+func (runnable *testRunnable) Run() error {
+	runnable.runs.Lock()
+	runnable.runs.hosts = append(runnable.runs.hosts, runnable.host.Name)
+	runnable.runs.counts[runnable.host.Name]++
+	var count int = runnable.runs.counts[runnable.host.Name]
+	runnable.runs.Unlock()
+	if runnable.run == nil {
+		return nil
+	}
+	return runnable.run(runnable.host.Name, runnable.session, count)
+}
+
+func (runnable *testRunnable) Stop() error     { return nil }
+func (runnable *testRunnable) Kill() error     { return nil }
+func (runnable *testRunnable) Pause() error    { return nil }
+func (runnable *testRunnable) Resume() error   { return nil }
+func (runnable *testRunnable) IsRunning() bool { return false }
+func (runnable *testRunnable) IsPaused() bool  { return false }
+func (runnable *testRunnable) UUID() string    { return runnable.uuid }
+
+func (runnable *testRunnable) Clone() threads.StepRunnable {
+	return newTestRunnable(runnable.runs, runnable.run)
+}
+
+func (runnable *testRunnable) SetClient(client generic.NetworkClient)  {}
+func (runnable *testRunnable) SetHost(host defaults.HostValue)         { runnable.host = host }
+func (runnable *testRunnable) SetSession(session module.Session)       { runnable.session = session }
+func (runnable *testRunnable) SetConfig(config defaults.ConfigPattern) {}
+
+func (runnable *testRunnable) Equals(r threads.StepRunnable) bool {
+	return r != nil && r.UUID() == runnable.uuid
+}
+
+// Fails the runs on the given hosts
+func failOn(hosts ...string) func(host string, session module.Session, count int) error {
+	return func(host string, session module.Session, count int) error {
+		for _, name := range hosts {
+			if name == host {
+				return fmt.Errorf("Step failed on %s", host)
+			}
+		}
+		return nil
+	}
+}
+
+// Creates a host group of LOCAL hosts with the given names
+func testGroup(name string, hosts ...string) defaults.HostGroups {
+	var group defaults.HostGroups = defaults.HostGroups{
+		Name:  name,
+		Hosts: make([]defaults.HostValue, 0),
+	}
+	for index, host := range hosts {
+		group.Hosts = append(group.Hosts, defaults.HostValue{Name: host, IpAddress: fmt.Sprintf("127.0.0.%v", index+1)})
+	}
+	return group
+}
+
+// Creates the hosts sessions, with LOCAL connection handlers
+func testSessions(groups []defaults.HostGroups) map[string]module.Session {
+	var netConfig *module.NetProtocolType = &module.NetProtocolType{NetProtocol: module.NET_PROTOCOL_LOCAL}
+	var sessionsMap map[string]module.Session = make(map[string]module.Session)
+	for index := range groups {
+		for _, host := range groups[index].Hosts {
+			handler, _ := local.NewConnectionHandler(false, false)
+			var session module.Session = module.NewSession(module.NewSessionId())
+			session.SetSystemObject("connection-handler", handler)
+			session.SetSystemObject("runtime-net", netConfig)
+			sessionsMap[inventory.SessionKey(&groups[index], host)] = session
+		}
+	}
+	return sessionsMap
+}
+
+// Executes a feed on the given LOCAL host groups
+func executeTestFeed(t *testing.T, groups []defaults.HostGroups, feed *module.FeedExec, sessionsMap map[string]module.Session) []error {
+	var config defaults.ConfigPattern = defaults.ConfigPattern{
+		Config:     &module.DeployConfig{MaxThreads: 4},
+		Net:        &module.NetProtocolType{NetProtocol: module.NET_PROTOCOL_LOCAL},
+		HostGroups: groups,
+	}
+	if sessionsMap == nil {
+		sessionsMap = testSessions(groups)
+	}
+	return ExecuteFeed(module.ConnectionConfig{UseUserPassword: true}, config, feed, sessionsMap, log.NewLogger("test", log.ERROR))
+}
+
+// Verifies that the feed errors contain the given text, or that there are no errors when empty
+func expectErrors(t *testing.T, errs []error, text string) {
+	t.Helper()
+	if text == "" {
+		if len(errs) > 0 {
+			t.Fatalf("Unexpected feed errors: %v", errs)
+		}
+		return
+	}
+	for _, err := range errs {
+		if strings.Contains(err.Error(), text) {
+			return
+		}
+	}
+	t.Fatalf("Expected a feed error containing %q, got %v", text, errs)
+}
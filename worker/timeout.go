@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-deploy/net/generic"
+	"github.com/hellgate75/go-deploy/types/threads"
+	"time"
+)
+
+// Step Runnable wrapper that fails the step when it doesn't complete within the timeout, closing the host client
+// and killing the step process. The host client can't be used after the expiry
+type timeoutRunnable struct {
+	threads.StepRunnable
+	client  generic.NetworkClient
+	timeout time.Duration
+	expired bool
+}
+
+// Runs the step process in a separate goroutine. When the timeout expires the goroutine is not waited: it ends when
+// the killed module returns, and its result is discarded in the buffered done channel
+func (runnable *timeoutRunnable) Run() error {
+	var done chan error = make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- errors.New(fmt.Sprintf("%v", r))
+			}
+		}()
+		done <- runnable.StepRunnable.Run()
+	}()
+	var timer *time.Timer = time.NewTimer(runnable.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		runnable.expired = true
+		if runnable.client != nil {
+			runnable.client.Close()
+		}
+		runnable.StepRunnable.Kill()
+		return errors.New(fmt.Sprintf("Step timeout expired after %s", runnable.timeout.String()))
+	}
+}
+
+// Verifies if the step timeout expired in the last run
+func (runnable *timeoutRunnable) Expired() bool {
+	return runnable.expired
+}
+
+func newTimeoutRunnable(runnable threads.StepRunnable, client generic.NetworkClient, timeout time.Duration) threads.StepRunnable {
+	return &timeoutRunnable{
+		StepRunnable: runnable,
+		client:       client,
+		timeout:      timeout,
+	}
+}
+
+// Retrieves the timeout of a step process, limited by the feed deadline when not zero
+func stepTimeout(timeout time.Duration, deadline time.Time) time.Duration {
+	if !deadline.IsZero() {
+		var remaining time.Duration = time.Until(deadline)
+		if remaining <= 0 {
+			remaining = time.Millisecond
+		}
+		if timeout <= 0 || remaining < timeout {
+			return remaining
+		}
+	}
+	return timeout
+}
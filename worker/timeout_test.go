@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"github.com/hellgate75/go-deploy/types/threads"
+	"testing"
+	"time"
+)
+
+// Step runnable blocked until killed
+type blockingRunnable struct {
+	threads.StepRunnable
+	killed chan struct{}
+}
+
+func (runnable *blockingRunnable) Run() error {
+	<-runnable.killed
+	return nil
+}
+
+func (runnable *blockingRunnable) Kill() error {
+	close(runnable.killed)
+	return nil
+}
+
+func TestTimeoutRunnableExpires(t *testing.T) {
+	var runnable *blockingRunnable = &blockingRunnable{killed: make(chan struct{})}
+	var thread threads.StepRunnable = newTimeoutRunnable(runnable, nil, 10*time.Millisecond)
+	if err := thread.Run(); err == nil {
+		t.Fatal("Expected a timeout error")
+	}
+	if !thread.(*timeoutRunnable).Expired() {
+		t.Fatal("Expected the timeout to be expired")
+	}
+	select {
+	case <-runnable.killed:
+	default:
+		t.Fatal("Expected the step process to be killed")
+	}
+}
+
+func TestStepTimeoutIsLimitedByDeadline(t *testing.T) {
+	if timeout := stepTimeout(time.Minute, time.Time{}); timeout != time.Minute {
+		t.Fatalf("Expected 1m without deadline, got %s", timeout)
+	}
+	if timeout := stepTimeout(time.Hour, time.Now().Add(time.Minute)); timeout > time.Minute || timeout <= 0 {
+		t.Fatalf("Expected the remaining time, got %s", timeout)
+	}
+	if timeout := stepTimeout(0, time.Now().Add(-time.Minute)); timeout != time.Millisecond {
+		t.Fatalf("Expected 1ms after the deadline, got %s", timeout)
+	}
+}
@@ -4,21 +4,38 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hellgate75/go-tcp-common/log"
+	"github.com/hellgate75/go-deploy/modules"
+	"github.com/hellgate75/go-deploy/modules/meta"
 	"github.com/hellgate75/go-deploy/net/generic"
 	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
+	"github.com/hellgate75/go-deploy/types/expr"
 	"github.com/hellgate75/go-deploy/types/module"
 	"github.com/hellgate75/go-deploy/types/threads"
 	"github.com/hellgate75/go-tcp-common/pool"
+	"time"
 )
 
 var clientsCache map[string]generic.NetworkClient = make(map[string]generic.NetworkClient)
 
-// Execute module.Step pointers list, recovering definition of per Session and Host Client components
+// Keeps the host clients connected at the end of a feed execution, so next executions reuse them
+var KeepConnections bool = false
+
+// Closes and removes all the cached host clients
+func CloseConnections() {
+	for key, client := range clientsCache {
+		client.Close()
+		delete(clientsCache, key)
+	}
+}
+
+// Execute module.Step pointers list, recovering definition of per Session and Host Client components.
+// Steps are stopped when the deadline expires, no deadline is applied if zero
 func ExecuteSteps(prefix string, steps []*module.Step,
 	selectedHostGroup *defaults.HostGroups, threadPool pool.ThreadPool,
 	errorsHandler *ErrorHandler, config defaults.ConfigPattern,
 	sessionsMap map[string]module.Session, logger log.Logger,
-	connectionConfig module.ConnectionConfig) []error {
+	connectionConfig module.ConnectionConfig, deadline time.Time) []error {
 	var errorsList []error = make([]error, 0)
 	defer func() {
 		if r := recover(); r != nil {
@@ -28,82 +45,100 @@ func ExecuteSteps(prefix string, steps []*module.Step,
 		}
 	}()
 	for _, step := range steps {
-		errorsHandler.Reset()
 		stepName := step.Name
 		if stepName == "" {
 			stepName = "<none>"
 		}
-		logger.Warnf("%s[ %s ]", prefix, stepName)
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			logger.Failuref("%s[ %s ] Feed timeout expired, step not executed", prefix, stepName)
+			errorsList = append(errorsList, errors.New(fmt.Sprintf("Feed timeout expired before step: %s", stepName)))
+			return errorsList
+		}
 		if step.StepData != nil {
 			thread := step.StepData.(threads.StepRunnable)
-			var threadsMap map[string]threads.StepRunnable = make(map[string]threads.StepRunnable)
-			for _, host := range selectedHostGroup.Hosts {
-				hostThread := thread.Clone()
-				sessMapId := fmt.Sprintf("%s-%s", selectedHostGroup.Name, host.Name)
-				if client, ok := clientsCache[sessMapId]; ok {
-					hostThread.SetClient(client)
+			if step.Loop == nil && step.LoopVar == "" {
+				logger.Warnf("%s[ %s ]", prefix, stepName)
+				errXList := executeStepThreads(step, thread, nil, selectedHostGroup, nil, threadPool,
+					errorsHandler, config, sessionsMap, logger, deadline)
+				errorsList = append(errorsList, errXList...)
+				if reason, aborted := errorsHandler.CheckPolicy(); aborted {
+					logger.Failuref("%s[ %s ] Feed aborted: %s", prefix, stepName, reason)
+					return errorsList
 				}
-				if session, ok := sessionsMap[sessMapId]; ok {
-					hostThread.SetSession(session)
+			} else {
+				var itemsMap map[string][]string = loopItems(step, selectedHostGroup, sessionsMap, logger)
+				var count int = 0
+				for _, items := range itemsMap {
+					if len(items) > count {
+						count = len(items)
+					}
 				}
-				hostThread.SetConfig(config)
-				hostThread.SetHost(host)
-				threadsMap[sessMapId] = hostThread
-				logger.Debugf("Scheduling step process for %s - %s ...", selectedHostGroup.Name, host.Name)
-				threadPool.Schedule(hostThread)
-				logger.Debugf("Scheduled step process for %s - %s!!", selectedHostGroup.Name, host.Name)
-			}
-			threadPool.Start()
-			err := threadPool.WaitFor()
-			threadPool.Stop()
-			if err != nil {
-				errorsList = append(errorsList, errors.New(fmt.Sprintf("%v", err)))
-				return errorsList
-			}
-			threadErrorsList := errorsHandler.GetAll()
-			if len(threadErrorsList) > 0 {
-				for _, host := range selectedHostGroup.Hosts {
-					sessMapId := fmt.Sprintf("%s-%s", selectedHostGroup.Name, host.Name)
-					var item ErrorItem = ErrorItem{}
-					if threadX, ok := threadsMap[sessMapId]; ok {
-						for _, errItem := range threadErrorsList {
-							if threadX.UUID() == errItem.UUID {
-								item = errItem
-								break
-							}
-						}
-						if item.UUID != "" && item.Error != nil {
-							logger.Failuref("- [Host: %s, Process Id: %s, status: ko]\n Error: %s", host.Name, threadX.UUID(), item.Error.Error())
-						} else {
-							logger.Successf("- [Host: %s, Process Id: %s, status: ok]", host.Name, threadX.UUID())
-						}
-					} else {
-						errorsList = append(errorsList, errors.New("Thread Map not present for group: "+selectedHostGroup.Name+" and host: "+host.Name))
-						return errorsList
+				if count == 0 {
+					logger.Warnf("%s[ %s ] [ no items ]", prefix, stepName)
+				}
+				var converter meta.Converter = nil
+				if count > 0 && hasLoopPlaceholders(step.Data) {
+					var errC error
+					converter, errC = modules.LoadConverterForModule(step.StepType)
+					if errC != nil {
+						logger.Errorf("%s[ %s ] Unable to replace the loop placeholders: %s", prefix, stepName, errC.Error())
+						errorsList = append(errorsList, errC)
+						converter = nil
 					}
 				}
-			} else {
-				for _, host := range selectedHostGroup.Hosts {
-					sessMapId := fmt.Sprintf("%s-%s", selectedHostGroup.Name, host.Name)
-					if threadX, ok := threadsMap[sessMapId]; ok {
-						logger.Successf("- [Host: %s, Process Id: %s, status: ok]", host.Name, threadX.UUID())
+				for index := 0; index < count; index++ {
+					if index < len(step.Loop) {
+						logger.Warnf("%s[ %s ] [ item %v: %s ]", prefix, stepName, index, step.Loop[index])
 					} else {
-						errorsList = append(errorsList, errors.New("Thread Map not present for group: "+selectedHostGroup.Name+" and host: "+host.Name))
+						logger.Warnf("%s[ %s ] [ item %v ]", prefix, stepName, index)
+					}
+					var hostsMap map[string]bool = make(map[string]bool)
+					var hostThreads map[string]threads.StepRunnable = make(map[string]threads.StepRunnable)
+					for sessMapId, items := range itemsMap {
+						if index < len(items) {
+							if converter != nil {
+								hostThread, errC := converter.Convert(replaceLoopPlaceholders(step.Data, items[index], index))
+								if errC != nil {
+									var err error = errors.New(fmt.Sprintf("Unable to convert step %s for item %s: %s", stepName, items[index], errC.Error()))
+									logger.Error(err.Error())
+									errorsList = append(errorsList, err)
+									if !step.IgnoreErrors {
+										errorsHandler.SetFailed(sessMapId, err)
+									}
+									continue
+								}
+								hostThreads[sessMapId] = hostThread
+							}
+							hostsMap[sessMapId] = true
+							sessionsMap[sessMapId].SetVar(LOOP_ITEM_VAR, items[index])
+							sessionsMap[sessMapId].SetVar(LOOP_INDEX_VAR, fmt.Sprintf("%v", index))
+						}
+					}
+					errXList := executeStepThreads(step, thread, hostThreads, selectedHostGroup, hostsMap, threadPool,
+						errorsHandler, config, sessionsMap, logger, deadline)
+					errorsList = append(errorsList, errXList...)
+					if reason, aborted := errorsHandler.CheckPolicy(); aborted {
+						clearLoopVars(itemsMap, sessionsMap)
+						logger.Failuref("%s[ %s ] Feed aborted: %s", prefix, stepName, reason)
 						return errorsList
 					}
 				}
+				clearLoopVars(itemsMap, sessionsMap)
 			}
-
 		} else {
+			logger.Warnf("%s[ %s ]", prefix, stepName)
 			logger.Warn("No step executable found, progressing with children or next step ...")
 		}
 		if step.Children != nil && len(step.Children) > 0 {
 			var subPrefix string = fmt.Sprintf("%s [ %s ]", prefix, stepName)
 			errXList := ExecuteSteps(subPrefix, step.Children, selectedHostGroup, threadPool, errorsHandler,
-										config, sessionsMap, logger, connectionConfig)
+										config, sessionsMap, logger, connectionConfig, deadline)
 			if len(errXList) > 0 {
 				errorsList = append(errorsList, errXList...)
 			}
+			if _, aborted := errorsHandler.Aborted(); aborted {
+				return errorsList
+			}
 		}
 		if step.Feeds != nil && len(step.Feeds) > 0 {
 			for _, feed := range step.Feeds {
@@ -112,9 +147,17 @@ func ExecuteSteps(prefix string, steps []*module.Step,
 					feedName = "<none>"
 				}
 				logger.Warnf("Executing Feed: %s children of Step %s", feedName, stepName)
-				errXList := ExecuteFeed(connectionConfig, config, feed, sessionsMap, logger)
+				errXList := executeFeed(connectionConfig, config, feed, sessionsMap, logger, deadline)
 				if len(errXList) > 0 {
 					errorsList = append(errorsList, errXList...)
+					if reason, aborted := errorsHandler.CheckPolicy(); aborted || errorsHandler.policy.FailFast {
+						if !aborted {
+							reason = fmt.Sprintf("Fail fast policy, feed %s failed", feedName)
+							errorsHandler.Abort(reason)
+						}
+						logger.Failuref("%s[ %s ] Feed aborted: %s", prefix, stepName, reason)
+						return errorsList
+					}
 				}
 			}
 		}
@@ -122,3 +165,201 @@ func ExecuteSteps(prefix string, steps []*module.Step,
 
 	return errorsList
 }
+
+// Runs a step on the selected group hosts, limited to the given hosts session keys if not nil, and reports the
+// per host status. Hosts with an entry in hostThreads run it in place of the step thread. Failing hosts are
+// retried according to the step retry options
+func executeStepThreads(step *module.Step, thread threads.StepRunnable, hostThreads map[string]threads.StepRunnable,
+	selectedHostGroup *defaults.HostGroups, hostsMap map[string]bool, threadPool pool.ThreadPool,
+	errorsHandler *ErrorHandler, config defaults.ConfigPattern, sessionsMap map[string]module.Session,
+	logger log.Logger, deadline time.Time) []error {
+	if CheckMode {
+		return checkStepThreads(step, thread, hostThreads, selectedHostGroup, hostsMap, errorsHandler, config, sessionsMap, logger)
+	}
+	var errorsList []error = make([]error, 0)
+	errorsHandler.Reset()
+	var threadsMap map[string]threads.StepRunnable = make(map[string]threads.StepRunnable)
+	var failedMap map[string]ErrorItem = make(map[string]ErrorItem)
+	var expiredMap map[string]bool = make(map[string]bool)
+	hosts, pending, skippedMap := selectStepHosts(step, selectedHostGroup, hostsMap, errorsHandler, sessionsMap, logger)
+	var attempts int = step.Retries + 1
+	var delay time.Duration = step.Delay
+	for attempt := 1; attempt <= attempts && len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			logger.Warnf("Retrying on %v host(s) in %s, attempt %v/%v ...", len(pending), delay.String(), attempt, attempts)
+			if delay > 0 {
+				time.Sleep(stepTimeout(delay, deadline))
+			}
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				logger.Warnf("Feed timeout expired, retry not executed on %v host(s)", len(pending))
+				break
+			}
+			if step.Backoff > 0 {
+				delay = time.Duration(float64(delay) * step.Backoff)
+			}
+		}
+		errorsHandler.SetAttempt(attempt)
+		for _, host := range pending {
+			sessMapId := inventory.SessionKey(selectedHostGroup, host)
+			hostThread := hostStepThread(thread, hostThreads, sessMapId).Clone()
+			client, hasClient := clientsCache[sessMapId]
+			if hasClient {
+				hostThread.SetClient(client)
+			}
+			if session, ok := sessionsMap[sessMapId]; ok {
+				hostThread.SetSession(session)
+			}
+			hostThread.SetConfig(config)
+			hostThread.SetHost(host)
+			var timeout time.Duration = stepTimeout(step.Timeout, deadline)
+			if timeout > 0 {
+				hostThread = newTimeoutRunnable(hostThread, client, timeout)
+			}
+			threadsMap[sessMapId] = hostThread
+			logger.Debugf("Scheduling step process for %s - %s ...", selectedHostGroup.Name, host.Name)
+			threadPool.Schedule(hostThread)
+			logger.Debugf("Scheduled step process for %s - %s!!", selectedHostGroup.Name, host.Name)
+		}
+		threadPool.Start()
+		err := threadPool.WaitFor()
+		threadPool.Stop()
+		if err != nil {
+			errorsList = append(errorsList, errors.New(fmt.Sprintf("%v", err)))
+			return errorsList
+		}
+		var failed []defaults.HostValue = make([]defaults.HostValue, 0)
+		for _, host := range pending {
+			sessMapId := inventory.SessionKey(selectedHostGroup, host)
+			var uuid string = threadsMap[sessMapId].UUID()
+			item, ko := errorsHandler.GetError(uuid)
+			if timeoutThread, ok := threadsMap[sessMapId].(*timeoutRunnable); ok && timeoutThread.Expired() {
+				// The host client has been closed, the host is not retried and fails, whatever the step options
+				delete(clientsCache, sessMapId)
+				expiredMap[sessMapId] = true
+				if !ko {
+					item = ErrorItem{UUID: uuid, Error: errors.New("Step timeout expired"), Attempt: attempt}
+				}
+				failedMap[sessMapId] = item
+				continue
+			}
+			if !ko && step.Until != "" {
+				done, errU := evaluateCondition(step.Until, sessionsMap[sessMapId])
+				if errU == nil && !done {
+					errU = errors.New("Until condition not met: " + step.Until)
+				}
+				if errU != nil {
+					errorsHandler.HandleError(uuid, errU)
+					item, ko = errorsHandler.GetError(uuid)
+				}
+			}
+			if ko {
+				failedMap[sessMapId] = item
+				failed = append(failed, host)
+				if attempt < attempts {
+					logger.Warnf("- [Host: %s, Process Id: %s, attempt: %v/%v, status: retry]\n Error: %s", host.Name, uuid, attempt, attempts, item.Error.Error())
+				}
+			} else {
+				delete(failedMap, sessMapId)
+			}
+		}
+		pending = failed
+	}
+	for _, host := range hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		if errS, ok := skippedMap[sessMapId]; ok {
+			logSkipped(logger, host.Name, step.When, errS, step.IgnoreErrors)
+			if errS != nil && !step.IgnoreErrors {
+				errorsHandler.SetFailed(sessMapId, errS)
+			}
+		} else if threadX, ok := threadsMap[sessMapId]; ok {
+			if item, ok := failedMap[sessMapId]; ok && step.IgnoreErrors && !expiredMap[sessMapId] {
+				logger.Warnf("- [Host: %s, Process Id: %s, status: ko, ignored]\n Error: %s", host.Name, threadX.UUID(), item.Error.Error())
+			} else if ok {
+				logger.Failuref("- [Host: %s, Process Id: %s, status: ko]\n Error: %s", host.Name, threadX.UUID(), item.Error.Error())
+				errorsHandler.SetFailed(sessMapId, item.Error)
+			} else {
+				logger.Successf("- [Host: %s, Process Id: %s, status: ok]", host.Name, threadX.UUID())
+			}
+		} else {
+			errorsList = append(errorsList, errors.New("Thread Map not present for group: "+selectedHostGroup.Name+" and host: "+host.Name))
+			return errorsList
+		}
+	}
+	return errorsList
+}
+
+// Selects the group hosts a step runs on, limited to the given hosts session keys if not nil and to the hosts matching
+// the step roles, and excluding the hosts failed in previous steps. It retrieves the selected hosts, the hosts to run and the hosts skipped by the step
+// condition, with the condition evaluation error if any
+func selectStepHosts(step *module.Step, selectedHostGroup *defaults.HostGroups, hostsMap map[string]bool,
+	errorsHandler *ErrorHandler, sessionsMap map[string]module.Session, logger log.Logger) ([]defaults.HostValue, []defaults.HostValue, map[string]error) {
+	var skippedMap map[string]error = make(map[string]error)
+	var hosts []defaults.HostValue = make([]defaults.HostValue, 0)
+	var pending []defaults.HostValue = make([]defaults.HostValue, 0)
+	roleGroup, errR := roleHosts(selectedHostGroup, step.Roles)
+	var roleHostsMap map[string]bool = make(map[string]bool)
+	if errR == nil {
+		for _, host := range roleGroup.Hosts {
+			roleHostsMap[host.Name] = true
+		}
+	}
+	for _, host := range selectedHostGroup.Hosts {
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
+		if hostsMap != nil && !hostsMap[sessMapId] {
+			continue
+		}
+		if errR == nil && !roleHostsMap[host.Name] {
+			logger.Debugf("Host %s - %s doesn't match the roles %s, excluded", selectedHostGroup.Name, host.Name, step.Roles)
+			continue
+		}
+		if errorsHandler.IsFailed(sessMapId) {
+			logger.Debugf("Host %s - %s failed a previous step, excluded", selectedHostGroup.Name, host.Name)
+			continue
+		}
+		hosts = append(hosts, host)
+		if errR != nil {
+			skippedMap[sessMapId] = errR
+			continue
+		}
+		if step.When != "" {
+			run, err := evaluateCondition(step.When, sessionsMap[sessMapId])
+			if err != nil {
+				skippedMap[sessMapId] = err
+				continue
+			}
+			if !run {
+				logger.Debugf("Condition %s is false for %s - %s", step.When, selectedHostGroup.Name, host.Name)
+				skippedMap[sessMapId] = nil
+				continue
+			}
+		}
+		pending = append(pending, host)
+	}
+	return hosts, pending, skippedMap
+}
+
+// Evaluates a step condition over the host session variables
+func evaluateCondition(when string, session module.Session) (bool, error) {
+	condition, err := expr.Parse(when)
+	if err != nil {
+		return false, err
+	}
+	return condition.Evaluate(func(name string) (string, bool) {
+		if session == nil {
+			return "", false
+		}
+		value, err := session.GetVar(name)
+		return value, err == nil
+	})
+}
+
+// Reports a host skipped by the step condition, or failed if the condition can't be evaluated
+func logSkipped(logger log.Logger, hostName string, when string, err error, ignoreErrors bool) {
+	if err != nil && ignoreErrors {
+		logger.Warnf("- [Host: %s, status: ko, ignored]\n Error: %s", hostName, err.Error())
+	} else if err != nil {
+		logger.Failuref("- [Host: %s, status: ko]\n Error: %s", hostName, err.Error())
+	} else {
+		logger.Warnf("- [Host: %s, status: skipped]\n Condition: %s", hostName, when)
+	}
+}
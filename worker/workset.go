@@ -7,15 +7,22 @@ import (
 	"github.com/hellgate75/go-tcp-common/log"
 	"github.com/hellgate75/go-tcp-common/pool"
 	"runtime"
-	"strings"
+	"sync"
+	"time"
 	
 	"github.com/hellgate75/go-deploy/net/generic"
 	"github.com/hellgate75/go-deploy/types/defaults"
+	"github.com/hellgate75/go-deploy/types/inventory"
 	"github.com/hellgate75/go-deploy/types/module"
 )
 
 // Execute Feed, after definition of per Session and Host Client components
 func ExecuteFeed(connectionConfig module.ConnectionConfig, config defaults.ConfigPattern, feed *module.FeedExec, sessionsMap map[string]module.Session, logger log.Logger) []error {
+	return executeFeed(connectionConfig, config, feed, sessionsMap, logger, time.Time{})
+}
+
+// Execute Feed within the given deadline, or the feed timeout if earlier, no deadline is applied if zero
+func executeFeed(connectionConfig module.ConnectionConfig, config defaults.ConfigPattern, feed *module.FeedExec, sessionsMap map[string]module.Session, logger log.Logger, deadline time.Time) []error {
 	var errorsList []error = make([]error, 0)
 	defer func() {
 		if r := recover(); r != nil {
@@ -30,26 +37,62 @@ func ExecuteFeed(connectionConfig module.ConnectionConfig, config defaults.Confi
 	}
 	logger.Infof("Executing on feed : %s", feedName)
 	logger.Infof("Hosts Group : %s", feed.HostGroup)
-	var selectedHostGroup *defaults.HostGroups = &defaults.HostGroups{}
-	for _, hg := range config.HostGroups {
-		if strings.ToLower(hg.Name) == strings.ToLower(feed.HostGroup) {
-			selectedHostGroup = &hg
-			break
+	if feed.Timeout > 0 {
+		var feedDeadline time.Time = time.Now().Add(feed.Timeout)
+		if deadline.IsZero() || feedDeadline.Before(deadline) {
+			deadline = feedDeadline
 		}
+		logger.Infof("Feed timeout : %s", feed.Timeout.String())
 	}
-	if selectedHostGroup == nil || selectedHostGroup.Hosts == nil {
-		errorsList = append(errorsList, errors.New("Unable to discover selected group in provided host groups ..."))
+	var limit string = ""
+	if config.Config != nil && config.Config.Limit != "" {
+		limit = config.Config.Limit
+		logger.Infof("Hosts Limit : %s", limit)
+	}
+	selectedHostGroup, errS := inventory.SelectHosts(config.HostGroups, feed.HostGroup, limit)
+	if errS != nil {
+		errorsList = append(errorsList, errors.New("Unable to discover selected hosts in provided host groups -> "+errS.Error()))
 		return errorsList
 	}
+	if feed.Roles != "" {
+		logger.Infof("Hosts Roles : %s", feed.Roles)
+		roleGroup, err := roleHosts(selectedHostGroup, feed.Roles)
+		if err != nil {
+			errorsList = append(errorsList, err)
+			return errorsList
+		}
+		if len(roleGroup.Hosts) == 0 {
+			logger.Warnf("No host of group %s matches the roles: %s", selectedHostGroup.Name, feed.Roles)
+			return errorsList
+		}
+		selectedHostGroup = roleGroup
+	}
+	errorsHandler := &ErrorHandler{
+		errorList:   make([]ErrorItem, 0),
+		attempt:     1,
+		policy:      FailurePolicy{
+			FailFast:          feed.FailFast,
+			MaxFailPercentage: feed.MaxFailPercentage,
+			HostsCount:        len(selectedHostGroup.Hosts),
+		},
+		failedHosts: make(map[string]error),
+	}
 	logger.Info("Selected Hosts: ")
 	for _, host := range selectedHostGroup.Hosts {
 		//create host client and open connection ...
 		logger.Infof("- %s", color.Yellow.Render(host.Name))
-		sessMapId := fmt.Sprintf("%s-%s", selectedHostGroup.Name, host.Name)
+		sessMapId := inventory.SessionKey(selectedHostGroup, host)
 		logger.Debugf("       -> session key: %s", color.Yellow.Render(sessMapId))
 		if session, ok := sessionsMap[sessMapId]; ok {
 			logger.Debugf("       -> session id: %s", session.GetSessionId())
-			if _, ok := clientsCache[sessMapId]; !ok {
+			if itf, err := session.GetSystemObject("connection-error"); err == nil && itf != nil {
+				// The host is excluded from the feed steps and reported as failed
+				var errH error = errors.New(fmt.Sprintf("%v", itf))
+				logger.Errorf("       -> Connection handler of host %s not available -> %v", host.Name, errH)
+				errorsHandler.SetFailed(sessMapId, errH)
+			} else if CheckMode {
+				logger.Debugf("       -> Check mode, client not connected")
+			} else if _, ok := clientsCache[sessMapId]; !ok {
 				itf, err := session.GetSystemObject("connection-handler")
 				var handler generic.ConnectionHandler
 				if err != nil {
@@ -59,12 +102,34 @@ func ExecuteFeed(connectionConfig module.ConnectionConfig, config defaults.Confi
 				handler = itf.(generic.ConnectionHandler)
 				logger.Debugf("       -> Handler Is present: %v", (handler != nil))
 				var client generic.NetworkClient
-				client, err = generic.ConnectHandlerViaConfig(connectionConfig, handler, host, config.Net, config.Config)
+				if host.JumpHost == nil {
+					host.JumpHost = selectedHostGroup.JumpHost
+				}
+				var hostConnectionConfig module.ConnectionConfig = connectionConfig
+				if itf, err := session.GetSystemObject("connection-config"); err == nil {
+					if sessionConnectionConfig, ok := itf.(module.ConnectionConfig); ok {
+						hostConnectionConfig = sessionConnectionConfig
+					}
+				}
+				var hostNet *module.NetProtocolType = config.Net
+				if itf, err := session.GetSystemObject("runtime-net"); err == nil {
+					if sessionNet, ok := itf.(*module.NetProtocolType); ok && sessionNet != nil {
+						hostNet = sessionNet
+					}
+				}
+				client, err = generic.ConnectHandlerViaConfig(hostConnectionConfig, handler, host, hostNet, config.Config)
 				if err != nil {
-					errorsList = append(errorsList, err)
-					return errorsList
+					// The host is excluded from the feed steps and reported as failed
+					logger.Errorf("       -> Connection to host %s failed -> %v", host.Name, err)
+					errorsHandler.SetFailed(sessMapId, errors.New(fmt.Sprintf("Connection failed -> %v", err)))
+					continue
+				}
+				if !KeepConnections {
+					defer func(key string, client generic.NetworkClient) {
+						client.Close()
+						delete(clientsCache, key)
+					}(sessMapId, client)
 				}
-				defer client.Close()
 				clientsCache[sessMapId] = client
 				logger.Debugf("       -> Client Is present and connected: %v", (client != nil))
 			} else {
@@ -75,45 +140,101 @@ func ExecuteFeed(connectionConfig module.ConnectionConfig, config defaults.Confi
 			return errorsList
 		}
 	}
+	if reason, aborted := errorsHandler.CheckPolicy(); aborted {
+		logger.Failuref("Feed aborted: %s", reason)
+	}
 	threadPool := pool.NewThreadPool(config.Config.MaxThreads, config.Config.ParallelExecutions)
 	threadPool.SetLogger(logger)
-	errorsHandler := &ErrorHandler{
-		errorList: make([]ErrorItem, 0),
-	}
 	threadPool.SetErrorHandler(errorsHandler)
 	defer threadPool.Stop()
-	errXList := ExecuteSteps("", feed.Steps, selectedHostGroup, threadPool,
-						errorsHandler, config, sessionsMap, logger, connectionConfig)
-	if len(errXList) > 0 {
-		errorsList = append(errorsList, errXList...)
+	var batches []*defaults.HostGroups = hostBatches(selectedHostGroup, feed.SerialCount, feed.SerialPercentage)
+	var notExecuted int = 0
+	for index, batch := range batches {
+		if _, aborted := errorsHandler.Aborted(); aborted {
+			for _, host := range batch.Hosts {
+				if !errorsHandler.IsFailed(inventory.SessionKey(batch, host)) {
+					notExecuted++
+				}
+			}
+			continue
+		}
+		if len(batches) > 1 {
+			logger.Warnf("Batch %v/%v, hosts: %s", index+1, len(batches), hostNames(batch))
+		}
+		errXList := ExecuteSteps("", feed.Steps, batch, threadPool,
+							errorsHandler, config, sessionsMap, logger, connectionConfig, deadline)
+		if len(errXList) > 0 {
+			errorsList = append(errorsList, errXList...)
+		}
+		if index < len(batches)-1 && batchFailed(batch, errorsHandler) {
+			errorsHandler.Abort(fmt.Sprintf("Hosts of batch %v/%v failed", index+1, len(batches)))
+		}
+	}
+	if CheckMode {
+		reportCheckPlan(feedName, selectedHostGroup, errorsHandler, logger)
 	}
+	errorsList = append(errorsList, reportFeedSummary(feedName, selectedHostGroup, notExecuted, errorsHandler, logger)...)
 
 	return errorsList
 }
 
 type ErrorItem struct {
-	UUID  string
-	Error error
+	UUID    string
+	Error   error
+	Attempt int
 }
 
 type ErrorHandler struct {
-	errorList []ErrorItem
+	sync.Mutex
+	errorList   []ErrorItem
+	attempt     int
+	policy      FailurePolicy
+	failedHosts map[string]error
+	aborted     string
+	plan        map[string][]string
 }
 
 func (handler *ErrorHandler) HandleError(uuid string, e error) {
 	if e != nil {
+		handler.Lock()
+		defer handler.Unlock()
 		handler.errorList = append(handler.errorList, ErrorItem{
-			UUID:  uuid,
-			Error: e,
+			UUID:    uuid,
+			Error:   e,
+			Attempt: handler.attempt,
 		})
 	}
 }
 
+// Sets the step attempt number, recorded in the next handled errors
+func (handler *ErrorHandler) SetAttempt(attempt int) {
+	handler.Lock()
+	defer handler.Unlock()
+	handler.attempt = attempt
+}
+
+// Retrieves the last error handled for the given process uuid, if any
+func (handler *ErrorHandler) GetError(uuid string) (ErrorItem, bool) {
+	handler.Lock()
+	defer handler.Unlock()
+	for idx := len(handler.errorList) - 1; idx >= 0; idx-- {
+		if handler.errorList[idx].UUID == uuid {
+			return handler.errorList[idx], true
+		}
+	}
+	return ErrorItem{}, false
+}
+
 func (handler *ErrorHandler) Reset() {
+	handler.Lock()
 	handler.errorList = make([]ErrorItem, 0)
+	handler.attempt = 1
+	handler.Unlock()
 	runtime.GC()
 }
 
 func (handler *ErrorHandler) GetAll() []ErrorItem {
+	handler.Lock()
+	defer handler.Unlock()
 	return handler.errorList
 }